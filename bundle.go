@@ -0,0 +1,240 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const definitionsPrefix = "#/definitions/"
+
+// InlineRefs returns a copy of s in which every reference to one of its
+// definitions is replaced by the definition itself, for consumers that
+// cannot follow "$ref". Recursive types cannot be fully inlined, so a
+// reference that appears within its own definition is kept as is, and only
+// the definitions still referenced that way remain in the result.
+//
+// This has the same effect as reflecting with Reflector.DoNotReference, but
+// also works on schemas that weren't just reflected, such as ones loaded
+// from disk.
+func InlineRefs(s *Schema) *Schema {
+	recursive := map[string]bool{}
+	root := inlineRefs(s.Type, s.Definitions, map[string]bool{}, recursive)
+
+	definitions := Definitions{}
+	// Definitions become recursive as they are inlined, so keep going until
+	// every one that is needed has been added.
+	for len(definitions) < len(recursive) {
+		for name := range recursive {
+			if _, ok := definitions[name]; !ok {
+				definitions[name] = inlineRefs(s.Definitions[name], s.Definitions, map[string]bool{name: true}, recursive)
+			}
+		}
+	}
+	if len(definitions) == 0 {
+		definitions = nil
+	}
+	return &Schema{Type: root, Definitions: definitions}
+}
+
+// inlineRefs inlines the references found in t, where inlining holds the
+// names of the definitions being inlined around t.
+func inlineRefs(t *Type, definitions Definitions, inlining, recursive map[string]bool) *Type {
+	inlined, _ := Transform(t, func(_ string, _, t *Type) (*Type, error) {
+		name := strings.TrimPrefix(t.Ref, definitionsPrefix)
+		def, ok := definitions[name]
+		switch {
+		case !ok || name == t.Ref:
+			return t, nil
+		case inlining[name]:
+			// The reference is kept along with the keywords beside it.
+			recursive[name] = true
+			ref := *t
+			return &ref, SkipSubschemas
+		default:
+			inlining[name] = true
+			defer delete(inlining, name)
+			return inlineRefs(def, definitions, inlining, recursive), SkipSubschemas
+		}
+	})
+	return inlined
+}
+
+// ExtractDefinitions returns a copy of s in which subschemas that appear more
+// than once are moved to the definitions and replaced by references to them.
+// Subschemas that are identical to an existing definition are replaced by a
+// reference to it, even if they only appear once. The root schema itself is
+// left in place. This is the inverse of InlineRefs.
+//
+// Only subschemas describing objects, arrays, enumerations or combinations
+// of other schemas are extracted. New definitions are named after their
+// title, or else after the property where they first appear.
+func ExtractDefinitions(s *Schema) *Schema {
+	definitions := Definitions{}
+	for name, def := range s.Definitions {
+		definitions[name] = def
+	}
+	root := s.Type
+	for {
+		e := &extractor{
+			counts:      map[string]int{},
+			names:       map[string]string{},
+			definitions: definitions,
+		}
+		for _, name := range sortedDefinitionNames(definitions) {
+			key := schemaKey(definitions[name])
+			if _, ok := e.names[key]; !ok {
+				e.names[key] = name
+			}
+			e.count(definitions[name])
+		}
+		e.count(root)
+
+		// Definitions are copied before being modified, so that s is left
+		// untouched.
+		extracted := Definitions{}
+		for name, def := range definitions {
+			extracted[name] = def
+		}
+		e.definitions = extracted
+		for _, name := range sortedDefinitionNames(definitions) {
+			extracted[name] = e.extractSubschemas(definitions[name], name)
+		}
+		root = e.extractSubschemas(root, "")
+		definitions = extracted
+		if !e.changed {
+			break
+		}
+	}
+	if len(definitions) == 0 {
+		definitions = nil
+	}
+	return &Schema{Type: root, Definitions: definitions}
+}
+
+type extractor struct {
+	counts      map[string]int
+	names       map[string]string
+	definitions Definitions
+	changed     bool
+}
+
+// extractable reports whether t is worth turning into a definition.
+func extractable(t *Type) bool {
+	return t != nil && t.Ref == "" && (t.Properties != nil || t.PatternProperties != nil || t.Items != nil ||
+		len(t.Enum) > 0 || len(t.AllOf) > 0 || len(t.AnyOf) > 0 || len(t.OneOf) > 0)
+}
+
+// schemaKey identifies t by its content.
+func schemaKey(t *Type) string {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func (e *extractor) count(t *Type) {
+	_ = Walk(t, func(_ string, _, t *Type) error {
+		if extractable(t) {
+			e.counts[schemaKey(t)]++
+		}
+		return nil
+	})
+}
+
+// extractSubschemas applies extract to the subschemas of t, where property
+// is the name of the property described by t, if any.
+func (e *extractor) extractSubschemas(t *Type, property string) *Type {
+	return t.mapSubschemas(func(ptr string, c *Type) *Type {
+		switch {
+		case strings.HasPrefix(ptr, "/properties/"):
+			return e.extract(c, unescapePointer(strings.TrimPrefix(ptr, "/properties/")))
+		case ptr == "/items" || ptr == "/additionalItems":
+			return e.extract(c, property)
+		default:
+			return e.extract(c, "")
+		}
+	})
+}
+
+// extract replaces t by a reference to a definition if it is repeated,
+// leaving its content to be extracted from on the next pass.
+func (e *extractor) extract(t *Type, property string) *Type {
+	if !extractable(t) {
+		return e.extractSubschemas(t, property)
+	}
+	key := schemaKey(t)
+	name, ok := e.names[key]
+	if !ok && e.counts[key] < 2 {
+		return e.extractSubschemas(t, property)
+	}
+	if !ok {
+		name = e.definitionName(t, property)
+		e.names[key] = name
+		e.definitions[name] = t
+	}
+	e.changed = true
+	return &Type{Ref: definitionsPrefix + name}
+}
+
+func (e *extractor) definitionName(t *Type, property string) string {
+	base := t.Title
+	if base == "" {
+		base = property
+	}
+	words := strings.FieldsFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i := range words {
+		words[i] = upperFirst(words[i])
+	}
+	base = strings.Join(words, "")
+	if base == "" {
+		base = "Definition"
+	}
+	name := base
+	for i := 2; ; i++ {
+		if _, ok := e.definitions[name]; !ok {
+			return name
+		}
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+}
+
+// PruneDefinitions returns a schema with the root of s and only those of its
+// definitions that can be reached from it.
+func PruneDefinitions(s *Schema) *Schema {
+	used := map[string]bool{}
+	var visit Visitor
+	visit = func(_ string, _, t *Type) error {
+		if name := strings.TrimPrefix(t.Ref, definitionsPrefix); name != t.Ref && !used[name] {
+			if def, ok := s.Definitions[name]; ok {
+				used[name] = true
+				_ = Walk(def, visit)
+			}
+		}
+		return nil
+	}
+	_ = Walk(s.Type, visit)
+
+	var definitions Definitions
+	for name := range used {
+		if definitions == nil {
+			definitions = Definitions{}
+		}
+		definitions[name] = s.Definitions[name]
+	}
+	return &Schema{Type: s.Type, Definitions: definitions}
+}
+
+func sortedDefinitionNames(definitions Definitions) []string {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,110 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type TreeNode struct {
+	Name     string      `json:"name"`
+	Children []*TreeNode `json:"children,omitempty"`
+}
+
+type Point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type Segment struct {
+	From Point `json:"from"`
+	To   Point `json:"to"`
+}
+
+func TestInlineRefs(t *testing.T) {
+	f, err := ioutil.ReadFile("fixtures/no_reference.json")
+	require.NoError(t, err)
+	expected := &Schema{}
+	require.NoError(t, json.Unmarshal(f, expected))
+
+	for _, s := range []*Schema{Reflect(&TestUser{}), loadSchema(t, "fixtures/defaults.json")} {
+		inlined := InlineRefs(s)
+		require.Nil(t, inlined.Definitions)
+
+		expectedJSON, _ := json.MarshalIndent(expected.Type, "", "  ")
+		actualJSON, _ := json.MarshalIndent(inlined.Type, "", "  ")
+		require.Equal(t, string(expectedJSON), string(actualJSON))
+	}
+}
+
+func TestInlineRefsRecursive(t *testing.T) {
+	inlined := InlineRefs(Reflect(&TreeNode{}))
+	require.Equal(t, "object", inlined.Type.Type)
+	require.Len(t, inlined.Definitions, 1)
+
+	children, _ := inlined.Properties.Get("children")
+	require.Equal(t, "#/definitions/TreeNode", children.(*Type).Items.Ref)
+
+	// Keywords beside recursive references are kept.
+	s := &Schema{
+		Type: &Type{Ref: "#/definitions/Node"},
+		Definitions: Definitions{
+			"Node": {Type: "object", Properties: props("next", &Type{
+				Ref:         "#/definitions/Node",
+				Description: "The next node.",
+				Extras:      map[string]interface{}{"x-order": 1},
+			})},
+		},
+	}
+	inlined = InlineRefs(s)
+	next, _ := inlined.Properties.Get("next")
+	require.Equal(t, &Type{
+		Ref:         "#/definitions/Node",
+		Description: "The next node.",
+		Extras:      map[string]interface{}{"x-order": 1},
+	}, next.(*Type))
+}
+
+func TestExtractDefinitions(t *testing.T) {
+	inlined := InlineRefs(Reflect(&Segment{}))
+	extracted := ExtractDefinitions(inlined)
+
+	require.Len(t, extracted.Definitions, 1)
+	from, _ := extracted.Properties.Get("from")
+	to, _ := extracted.Properties.Get("to")
+	require.Equal(t, "#/definitions/From", from.(*Type).Ref)
+	require.Equal(t, "#/definitions/From", to.(*Type).Ref)
+
+	// The input must not be modified.
+	from, _ = inlined.Properties.Get("from")
+	require.Equal(t, "object", from.(*Type).Type)
+
+	// Subschemas matching existing definitions are replaced by references.
+	s := Reflect(&Segment{})
+	s.Type = InlineRefs(s).Type
+	extracted = ExtractDefinitions(s)
+	from, _ = extracted.Properties.Get("from")
+	require.Equal(t, "#/definitions/Point", from.(*Type).Ref)
+	require.Len(t, extracted.Definitions, 2)
+}
+
+func TestPruneDefinitions(t *testing.T) {
+	s := Reflect(&Segment{})
+	s.Definitions["Unused"] = &Type{Type: "string"}
+
+	pruned := PruneDefinitions(s)
+	require.Len(t, pruned.Definitions, 2)
+	require.NotContains(t, pruned.Definitions, "Unused")
+	require.Contains(t, s.Definitions, "Unused")
+}
+
+func loadSchema(t *testing.T, path string) *Schema {
+	t.Helper()
+	f, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	s := &Schema{}
+	require.NoError(t, json.Unmarshal(f, s))
+	return s
+}
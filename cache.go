@@ -0,0 +1,199 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+// defaultReflector is used by the package level functions.
+var defaultReflector = &Reflector{}
+
+// reflectCache holds the schemas of the struct types reflected by a
+// Reflector with Cache set, for the options it was last used with. Schemas
+// are never handed out directly, only clones of them, so they stay unchanged
+// however callers modify the results.
+type reflectCache struct {
+	mu      sync.Mutex
+	options *Reflector
+	// comments identifies the CommentMap of options, which isn't copied.
+	comments mapIdentity
+	structs  map[reflect.Type]*cachedStruct
+}
+
+// mapIdentity tells maps apart by identity and size, without reading them.
+type mapIdentity struct {
+	ptr uintptr
+	len int
+}
+
+func identifyMap(m map[string]string) mapIdentity {
+	return mapIdentity{ptr: reflect.ValueOf(m).Pointer(), len: len(m)}
+}
+
+// A cachedStruct is the schema of a struct type, before the defaults given by
+// its JSONSchemaDefaults method are applied, along with the types reflected
+// by its fields. Reflecting those types again adds the definitions the
+// schema refers to, just as reflecting the struct did.
+type cachedStruct struct {
+	st   *Type
+	deps []reflect.Type
+}
+
+// reflectCacheInit guards the creation of the caches of Reflectors.
+var reflectCacheInit sync.Mutex
+
+// structCache returns the cache of r for its current options, cleared if
+// they changed since it was last used, or nil if schemas aren't cached. The
+// options are compared with those the cache was last used with, apart from
+// CommentMap, whose identity and size are compared instead so that the cost
+// doesn't grow with it, and are only copied when they changed.
+func (r *Reflector) structCache() *reflectCache {
+	if !r.cacheable() {
+		return nil
+	}
+	reflectCacheInit.Lock()
+	if r.cache == nil {
+		r.cache = &reflectCache{}
+	}
+	cache := r.cache
+	reflectCacheInit.Unlock()
+
+	options := *r
+	options.cache, options.CommentMap = nil, nil
+	comments := identifyMap(r.CommentMap)
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.options == nil || cache.comments != comments || !reflect.DeepEqual(cache.options, &options) {
+		cache.options = r.snapshot()
+		cache.comments = comments
+		cache.structs = map[reflect.Type]*cachedStruct{}
+	}
+	return cache
+}
+
+// cacheable returns whether schemas reflected by r are cached. Options
+// holding functions can't be told apart by what they do, and TagReaders other
+// than structs may change what they read, so none are cached while they are
+// set. Schemas inlined with DoNotReference or named with
+// DisambiguateTypeNames depend on the other types reflected with them.
+func (r *Reflector) cacheable() bool {
+	if !r.Cache || r.DoNotReference || r.DisambiguateTypeNames ||
+		r.TypeMapper != nil || r.TypeNamer != nil || r.TypeIDer != nil ||
+		r.AdditionalFields != nil || r.FieldMapper != nil || r.KeyNamer != nil {
+		return false
+	}
+	for _, reader := range r.TagReaders {
+		if reflect.TypeOf(reader).Kind() != reflect.Struct {
+			return false
+		}
+	}
+	return true
+}
+
+// snapshot returns a copy of the options of r, apart from CommentMap, that
+// shares nothing with them, so that it doesn't change with them.
+func (r *Reflector) snapshot() *Reflector {
+	c := *r
+	c.cache, c.CommentMap = nil, nil
+	// Copies keep nil slices nil and empty ones empty, so that the snapshot
+	// still equals the options.
+	c.IgnoredTypes = append(r.IgnoredTypes[:0:0], r.IgnoredTypes...)
+	c.TagReaders = append(r.TagReaders[:0:0], r.TagReaders...)
+	c.TagKeys = append(r.TagKeys[:0:0], r.TagKeys...)
+	for i, key := range r.TagKeys {
+		c.TagKeys[i].Optional = append(key.Optional[:0:0], key.Optional...)
+		c.TagKeys[i].OptionalNilable = append(key.OptionalNilable[:0:0], key.OptionalNilable...)
+		c.TagKeys[i].Inline = append(key.Inline[:0:0], key.Inline...)
+		c.TagKeys[i].Remain = append(key.Remain[:0:0], key.Remain...)
+		c.TagKeys[i].Quoted = append(key.Quoted[:0:0], key.Quoted...)
+		c.TagKeys[i].Ignore = append(key.Ignore[:0:0], key.Ignore...)
+	}
+	return &c
+}
+
+// load returns a clone of the cached schema of the struct type t and the
+// types its fields reflected. A nil cache holds nothing.
+func (c *reflectCache) load(t reflect.Type) (*Type, []reflect.Type, bool) {
+	if c == nil {
+		return nil, nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.structs[t]
+	if !ok {
+		return nil, nil, false
+	}
+	return cloner{}.clone(cached.st), cached.deps, true
+}
+
+// store caches a clone of st, the schema of the struct type t, whose fields
+// reflected deps.
+func (c *reflectCache) store(t reflect.Type, st *Type, deps []reflect.Type) {
+	st = cloner{}.clone(st)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.structs[t] = &cachedStruct{st: st, deps: deps}
+}
+
+// A cloner deep copies schemas, mapping each original to its copy.
+type cloner map[*Type]*Type
+
+func (c cloner) clone(t *Type) *Type {
+	if t == nil {
+		return nil
+	}
+	if clone, ok := c[t]; ok {
+		return clone
+	}
+	clone := t.mapSubschemas(func(_ string, s *Type) *Type {
+		return c.clone(s)
+	})
+	clone.AdditionalProperties = append(json.RawMessage(nil), clone.AdditionalProperties...)
+	if t.Required != nil {
+		clone.Required = append(make([]string, 0, len(t.Required)), t.Required...)
+	}
+	if t.AdditionalTypes != nil {
+		clone.AdditionalTypes = append(make([]string, 0, len(t.AdditionalTypes)), t.AdditionalTypes...)
+	}
+	clone.Enum = cloneValues(t.Enum)
+	clone.Examples = cloneValues(t.Examples)
+	clone.Default = cloneValue(t.Default)
+	if t.Extras != nil {
+		clone.Extras = make(map[string]interface{}, len(t.Extras))
+		for k, v := range t.Extras {
+			clone.Extras[k] = cloneValue(v)
+		}
+	}
+	c[t] = clone
+	return clone
+}
+
+func cloneValues(vs []interface{}) []interface{} {
+	if vs == nil {
+		return nil
+	}
+	clone := make([]interface{}, len(vs))
+	for i, v := range vs {
+		clone[i] = cloneValue(v)
+	}
+	return clone
+}
+
+// cloneValue copies the maps and slices that values of keywords are made of
+// when decoded from JSON or given by tags. Other values are shared.
+func cloneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		return cloneValues(v)
+	case []string:
+		return append([]string(nil), v...)
+	case map[string]interface{}:
+		clone := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			clone[k] = cloneValue(e)
+		}
+		return clone
+	}
+	return v
+}
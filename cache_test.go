@@ -0,0 +1,194 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/jsonschema/examples"
+)
+
+func TestReflectorCache(t *testing.T) {
+	r := &Reflector{Cache: true}
+	first := r.Reflect(&TestUser{})
+	expected, err := json.Marshal(first)
+	require.NoError(t, err)
+	require.NotEmpty(t, r.cache.structs)
+
+	// Changing a result doesn't change those that follow.
+	first.Definitions["TestUser"].Properties.Delete("id")
+	first.Definitions["TestUser"].Required[0] = "changed"
+	first.Definitions["GrandfatherType"].Extras = map[string]interface{}{"x": 1}
+
+	second := r.Reflect(&TestUser{})
+	require.True(t, first.Definitions["TestUser"] != second.Definitions["TestUser"])
+	actual, err := json.Marshal(second)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(actual))
+
+	second.Definitions["TestUser"].Properties.Delete("id")
+	actual, err = json.Marshal(r.Reflect(&TestUser{}))
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(actual))
+}
+
+func TestReflectorCacheOff(t *testing.T) {
+	r := &Reflector{}
+	r.Reflect(&TestUser{})
+	require.Nil(t, r.cache)
+
+	// Functions can't be told apart, so aren't cached.
+	r = &Reflector{Cache: true}
+	for _, prefix := range []string{"a", "b"} {
+		prefix := prefix
+		r.TypeNamer = func(t reflect.Type) string { return prefix + t.Name() }
+		require.Contains(t, r.Reflect(&TestUser{}).Definitions, prefix+"TestUser")
+	}
+	require.Nil(t, r.cache)
+}
+
+// TestReflectorCacheMatchesUncached reflects types sharing fields of the
+// same types in turn, so that they are partly taken from the cache.
+func TestReflectorCacheMatchesUncached(t *testing.T) {
+	types := []interface{}{
+		&TestUser{}, &RootOneOf{}, &Outer{}, &TestNullable{}, &ValidatedUser{}, &examples.User{},
+		&UsesProvidedDefaults{}, &ProvidedDefaults{}, &TreeNode{}, &Household{}, &TestYamlInline{},
+	}
+	cached := &Reflector{Cache: true, TagReaders: []TagReader{ValidateTagReader{}}}
+	for _, expanded := range []bool{false, true, false} {
+		cached.ExpandedStruct = expanded
+		for i := 0; i < 2; i++ {
+			for _, v := range types {
+				uncached := &Reflector{ExpandedStruct: expanded, TagReaders: cached.TagReaders}
+				expected, err := json.Marshal(uncached.Reflect(v))
+				require.NoError(t, err)
+				actual, err := json.Marshal(cached.Reflect(v))
+				require.NoError(t, err)
+				require.Equal(t, string(expected), string(actual), "%T", v)
+			}
+		}
+	}
+}
+
+func TestReflectorCacheOptions(t *testing.T) {
+	r := &Reflector{Cache: true}
+	require.Equal(t, "false", string(r.Reflect(&TestUser{}).Definitions["TestUser"].AdditionalProperties))
+	r.AllowAdditionalProperties = true
+	require.Equal(t, "true", string(r.Reflect(&TestUser{}).Definitions["TestUser"].AdditionalProperties))
+	r.AllowAdditionalProperties = false
+	require.Equal(t, "false", string(r.Reflect(&TestUser{}).Definitions["TestUser"].AdditionalProperties))
+
+	r.CommentMap = map[string]string{}
+	require.Empty(t, r.Reflect(&TestUser{}).Definitions["GrandfatherType"].Description)
+	r.CommentMap["github.com/alecthomas/jsonschema.GrandfatherType"] = "The grandfather."
+	require.Equal(t, "The grandfather.", r.Reflect(&TestUser{}).Definitions["GrandfatherType"].Description)
+	r.CommentMap = map[string]string{"github.com/alecthomas/jsonschema.GrandfatherType": "The grandparent."}
+	require.Equal(t, "The grandparent.", r.Reflect(&TestUser{}).Definitions["GrandfatherType"].Description)
+
+	r.TagReaders = []TagReader{ValidateTagReader{}}
+	require.Equal(t, 20, propertyMap(r.Reflect(&ValidatedUser{}).Definitions["ValidatedUser"])["name"].MaxLength)
+	r.TagReaders = []TagReader{ValidateTagReader{TagName: "binding"}}
+	require.Equal(t, 0, propertyMap(r.Reflect(&ValidatedUser{}).Definitions["ValidatedUser"])["name"].MaxLength)
+
+	r.TagKeys = []TagKey{JSONTagKey}
+	r.TagKeys[0].Optional = []string{"omitempty"}
+	r.Reflect(&TestUser{})
+	r.TagKeys[0].Optional[0] = "never"
+	require.Contains(t, r.Reflect(&TestUser{}).Definitions["TestUser"].Required, "birth_date")
+}
+
+func TestReflectorCacheKept(t *testing.T) {
+	// Unchanged options, empty or not, keep the cache.
+	for _, r := range []*Reflector{
+		{Cache: true},
+		{Cache: true, IgnoredTypes: []interface{}{}, TagKeys: []TagKey{}, CommentMap: map[string]string{}},
+		{Cache: true, TagKeys: []TagKey{JSONTagKey, {Name: "yaml", Optional: []string{}}}, CommentMap: map[string]string{"a": "b"}},
+	} {
+		r.Reflect(&TestUser{})
+		structs := r.cache.structs
+		require.NotEmpty(t, structs)
+		r.Reflect(&TestUser{})
+		require.Equal(t, reflect.ValueOf(structs).Pointer(), reflect.ValueOf(r.cache.structs).Pointer())
+	}
+}
+
+func TestClonerSharing(t *testing.T) {
+	r := &Reflector{DoNotReference: true}
+	s := r.Reflect(&Outer{})
+	c := cloner{}
+	clone := &Schema{Type: c.clone(s.Type), Definitions: Definitions{}}
+	for name, def := range s.Definitions {
+		clone.Definitions[name] = c.clone(def)
+	}
+	require.Equal(t, s, clone)
+
+	// Definitions inlined with DoNotReference are the same schemas as those
+	// in place of references, and stay so in the clone.
+	for name, def := range s.Definitions {
+		require.True(t, def != clone.Definitions[name], name)
+	}
+	require.True(t, clone.Definitions["Outer"] == clone.Type)
+}
+
+func TestReflectorConcurrent(t *testing.T) {
+	reflectors := []*Reflector{{Cache: true}, {Cache: true, ExpandedStruct: true}, {Cache: true, DoNotReference: true}, defaultReflector}
+	expected := make([]string, len(reflectors))
+	for i, r := range reflectors {
+		b, err := json.Marshal((&Reflector{ExpandedStruct: r.ExpandedStruct, DoNotReference: r.DoNotReference}).Reflect(&TestUser{}))
+		require.NoError(t, err)
+		expected[i] = string(b)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 64)
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				n := (g + i) % len(reflectors)
+				s := reflectors[n].Reflect(&TestUser{})
+				// Results belong to the caller, who may change them.
+				s.Description = "changed"
+				s.Description = ""
+				b, err := json.Marshal(s)
+				if err != nil {
+					errs <- err.Error()
+				} else if string(b) != expected[n] {
+					errs <- string(b)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func BenchmarkReflect(b *testing.B) {
+	r := &Reflector{Cache: true}
+	for i := 0; i < b.N; i++ {
+		r.Reflect(&TestUser{})
+	}
+}
+
+func BenchmarkReflectUncached(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		r := &Reflector{}
+		r.Reflect(&TestUser{})
+	}
+}
+
+func BenchmarkReflectParallel(b *testing.B) {
+	r := &Reflector{Cache: true}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			r.Reflect(&TestUser{})
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// MarshalCanonical returns s as JSON in a canonical form, so that generated
+// files can be checked in and compared without noise: the keys of every
+// object, keywords and definitions included, are sorted, apart from those of
+// the "properties" keyword of each schema, which keep the order of Properties
+// (see
+// Reflector.SortProperties). The output is indented by two spaces and ends
+// with a newline, and characters such as "<" and "&" are not escaped. It
+// only depends on s, and not on how the Go version in use orders or escapes
+// JSON.
+func MarshalCanonical(s *Schema) ([]byte, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	compact := &bytes.Buffer{}
+	if err := writeCanonical(compact, dec, canonicalSchema); err != nil {
+		return nil, err
+	}
+	out := &bytes.Buffer{}
+	if err := json.Indent(out, compact.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// canonicalKind tells writeCanonical what a JSON value is, so that it only
+// keeps the order of the properties of schemas, and not that of any object
+// that merely has a "properties" key, such as a property of that name.
+type canonicalKind int
+
+const (
+	// canonicalValue is any value, such as a default or an extra keyword.
+	canonicalValue canonicalKind = iota
+	// canonicalSchema is a schema, or an array of them.
+	canonicalSchema
+	// canonicalSchemas is an object of schemas, such as definitions.
+	canonicalSchemas
+	// canonicalProperties is the properties of a schema, in their order.
+	canonicalProperties
+)
+
+// canonicalKeywords gives the kinds of the keywords of a schema that hold
+// schemas.
+var canonicalKeywords = map[string]canonicalKind{
+	"properties":           canonicalProperties,
+	"patternProperties":    canonicalSchemas,
+	"dependencies":         canonicalSchemas,
+	"definitions":          canonicalSchemas,
+	"$defs":                canonicalSchemas,
+	"additionalItems":      canonicalSchema,
+	"items":                canonicalSchema,
+	"additionalProperties": canonicalSchema,
+	"allOf":                canonicalSchema,
+	"anyOf":                canonicalSchema,
+	"oneOf":                canonicalSchema,
+	"not":                  canonicalSchema,
+	"media":                canonicalSchema,
+}
+
+// writeCanonical writes the next JSON value from dec, of the given kind, to
+// b in canonical form. The keys of objects are sorted unless they are the
+// properties of a schema.
+func writeCanonical(b *bytes.Buffer, dec *json.Decoder, kind canonicalKind) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch token {
+	case json.Delim('{'):
+		var keys []string
+		values := map[string][]byte{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			valueKind := canonicalValue
+			switch kind {
+			case canonicalSchema:
+				valueKind = canonicalKeywords[key.(string)]
+			case canonicalSchemas, canonicalProperties:
+				valueKind = canonicalSchema
+			}
+			value := &bytes.Buffer{}
+			if err := writeCanonical(value, dec, valueKind); err != nil {
+				return err
+			}
+			// Duplicate keys, which Extras can give, take the last value as
+			// they do when decoded.
+			if _, ok := values[key.(string)]; !ok {
+				keys = append(keys, key.(string))
+			}
+			values[key.(string)] = value.Bytes()
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		if kind != canonicalProperties {
+			sort.Strings(keys)
+		}
+		b.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeCanonicalString(b, key)
+			b.WriteByte(':')
+			b.Write(values[key])
+		}
+		b.WriteByte('}')
+
+	case json.Delim('['):
+		// Arrays in place of a schema, as in allOf, hold schemas.
+		itemKind := canonicalValue
+		if kind == canonicalSchema {
+			itemKind = canonicalSchema
+		}
+		b.WriteByte('[')
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeCanonical(b, dec, itemKind); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		b.WriteByte(']')
+
+	default:
+		if s, ok := token.(string); ok {
+			writeCanonicalString(b, s)
+			return nil
+		}
+		v, err := json.Marshal(token)
+		if err != nil {
+			return err
+		}
+		b.Write(v)
+	}
+	return nil
+}
+
+func writeCanonicalString(b *bytes.Buffer, s string) {
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	// Encode terminates values with a newline.
+	b.Truncate(b.Len() - 1)
+}
@@ -0,0 +1,155 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type CanonicalBase struct {
+	Zone string `json:"zone"`
+	ID   int    `json:"id"`
+}
+
+type CanonicalExtra struct {
+	Name string `json:"name,omitempty"`
+}
+
+// CanonicalMoved has the same fields as CanonicalBase and CanonicalExtra,
+// which have been moved between embedded structs.
+type CanonicalMoved struct {
+	Name string `json:"name,omitempty"`
+	ID   int    `json:"id"`
+}
+
+type CanonicalV1 struct {
+	CanonicalBase
+	CanonicalExtra
+	Pattern string `json:"pattern" jsonschema:"pattern=^<[a-z]+>&$" jsonschema_extras:"x-b=2,x-a=1"`
+}
+
+type CanonicalV2 struct {
+	CanonicalMoved
+	Pattern string `json:"pattern" jsonschema:"pattern=^<[a-z]+>&$" jsonschema_extras:"x-b=2,x-a=1"`
+	Zone    string `json:"zone"`
+}
+
+func TestMarshalCanonical(t *testing.T) {
+	b, err := MarshalCanonical(Reflect(&TestUser{}))
+	require.NoError(t, err)
+	expected, err := ioutil.ReadFile("fixtures/canonical.json")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(b))
+
+	// The canonical form is the same schema.
+	original, err := json.Marshal(Reflect(&TestUser{}))
+	require.NoError(t, err)
+	require.JSONEq(t, string(original), string(b))
+}
+
+func TestMarshalCanonicalOrder(t *testing.T) {
+	s := &Schema{
+		Type: &Type{
+			Type:       "object",
+			Properties: props("zebra", &Type{Type: "string", Pattern: "<&>"}, "apple", &Type{Type: "integer"}),
+			Extras:     map[string]interface{}{"x-zeta": map[string]interface{}{"b": 1, "a": []interface{}{2, 1}}, "additionalItems": true},
+		},
+	}
+	b, err := MarshalCanonical(s)
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "additionalItems": true,
+  "properties": {
+    "zebra": {
+      "pattern": "<&>",
+      "type": "string"
+    },
+    "apple": {
+      "type": "integer"
+    }
+  },
+  "type": "object",
+  "x-zeta": {
+    "a": [
+      2,
+      1
+    ],
+    "b": 1
+  }
+}
+`, string(b))
+}
+
+func TestMarshalCanonicalPropertiesKey(t *testing.T) {
+	// Objects that only have a key named "properties" are sorted, and so are
+	// the keywords of properties named "properties".
+	s := &Schema{
+		Type: &Type{
+			Type: "object",
+			Properties: props(
+				"properties", &Type{Type: "object", Properties: props("b", &Type{Type: "string"}, "a", &Type{Type: "string"})},
+				"other", &Type{Type: "string"},
+			),
+			Extras: map[string]interface{}{"x-data": map[string]interface{}{"properties": map[string]interface{}{"b": 1, "a": 2}}},
+		},
+		Definitions: Definitions{
+			"properties": &Type{Type: "string", Title: "properties"},
+		},
+	}
+	b, err := MarshalCanonical(s)
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "definitions": {
+    "properties": {
+      "title": "properties",
+      "type": "string"
+    }
+  },
+  "properties": {
+    "properties": {
+      "properties": {
+        "b": {
+          "type": "string"
+        },
+        "a": {
+          "type": "string"
+        }
+      },
+      "type": "object"
+    },
+    "other": {
+      "type": "string"
+    }
+  },
+  "type": "object",
+  "x-data": {
+    "properties": {
+      "a": 2,
+      "b": 1
+    }
+  }
+}
+`, string(b))
+}
+
+func TestSortProperties(t *testing.T) {
+	// Moving fields between embedded structs doesn't change the schema.
+	r := &Reflector{SortProperties: true}
+	v1, err := MarshalCanonical(&Schema{Type: r.Reflect(&CanonicalV1{}).Definitions["CanonicalV1"]})
+	require.NoError(t, err)
+	v2, err := MarshalCanonical(&Schema{Type: r.Reflect(&CanonicalV2{}).Definitions["CanonicalV2"]})
+	require.NoError(t, err)
+	require.Equal(t, string(v1), string(v2))
+
+	def := r.Reflect(&CanonicalV1{}).Definitions["CanonicalV1"]
+	require.Equal(t, []string{"id", "name", "pattern", "zone"}, def.Properties.Keys())
+	require.Equal(t, []string{"id", "pattern", "zone"}, def.Required)
+
+	def = (&Reflector{}).Reflect(&CanonicalV1{}).Definitions["CanonicalV1"]
+	require.Equal(t, []string{"zone", "id", "name", "pattern"}, def.Properties.Keys())
+
+	r.ExpandedStruct = true
+	require.Equal(t, []string{"id", "name", "pattern", "zone"}, r.Reflect(&CanonicalV2{}).Properties.Keys())
+}
@@ -0,0 +1,110 @@
+// Command jsonschema-comments extracts Go comments at build time and writes
+// them to a Go file as a map that can be assigned to Reflector.CommentMap.
+//
+// This allows binaries to include type and field descriptions without the
+// source tree being available at runtime. It is intended to be used from a
+// go:generate directive placed in the package whose comments are extracted:
+//
+//	//go:generate go run github.com/alecthomas/jsonschema/cmd/jsonschema-comments -base github.com/example/pkg -o comments_gen.go
+//
+// The generated variable can then be used as:
+//
+//	r := &jsonschema.Reflector{CommentMap: CommentMap}
+//
+// With -check, the file is not written; instead the command fails if the
+// existing file does not match what would be generated.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	gopath "path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/jsonschema"
+)
+
+func main() {
+	var (
+		base   = flag.String("base", "", "import path of the package found at -path (required)")
+		path   = flag.String("path", ".", "directory to extract comments from, including sub-directories")
+		pkg    = flag.String("pkg", os.Getenv("GOPACKAGE"), "package name of the generated file (defaults to $GOPACKAGE)")
+		name   = flag.String("var", "CommentMap", "name of the generated variable")
+		output = flag.String("o", "comments_gen.go", "file to write")
+		check  = flag.Bool("check", false, "fail if the output file is missing or stale instead of writing it")
+	)
+	flag.Parse()
+	if *base == "" || *pkg == "" {
+		fmt.Fprintln(os.Stderr, "jsonschema-comments: -base and -pkg are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	src, err := generate(*base, *path, *pkg, *name)
+	if err != nil {
+		fatalf("%s", err)
+	}
+
+	if *check {
+		existing, err := ioutil.ReadFile(*output)
+		if err != nil {
+			fatalf("%s", err)
+		}
+		if !bytes.Equal(existing, src) {
+			fatalf("%s is stale, run go generate", *output)
+		}
+		return
+	}
+
+	if err := ioutil.WriteFile(*output, src, 0644); err != nil {
+		fatalf("%s", err)
+	}
+}
+
+// generate extracts the comments found in path and returns the formatted
+// source of a Go file declaring them as a map literal.
+func generate(base, path, pkg, name string) ([]byte, error) {
+	extracted := map[string]string{}
+	if err := jsonschema.ExtractGoComments(base, path, extracted); err != nil {
+		return nil, err
+	}
+
+	// ExtractGoComments joins base with the walked directories, so when path
+	// isn't "." the keys need to be rebased onto the import path.
+	prefix := gopath.Join(base, filepath.ToSlash(path))
+	comments := make(map[string]string, len(extracted))
+	keys := make([]string, 0, len(extracted))
+	for k, v := range extracted {
+		if strings.HasPrefix(k, prefix) {
+			k = base + strings.TrimPrefix(k, prefix)
+		}
+		comments[k] = v
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintln(buf, "// Code generated by jsonschema-comments. DO NOT EDIT.")
+	fmt.Fprintln(buf)
+	fmt.Fprintf(buf, "package %s\n\n", pkg)
+	fmt.Fprintf(buf, "// %s holds the Go comments extracted from %s,\n", name, base)
+	fmt.Fprintln(buf, "// suitable for use as jsonschema.Reflector.CommentMap.")
+	fmt.Fprintf(buf, "var %s = map[string]string{\n", name)
+	for _, k := range keys {
+		fmt.Fprintf(buf, "%s: %s,\n", strconv.Quote(k), strconv.Quote(comments[k]))
+	}
+	fmt.Fprintln(buf, "}")
+	return format.Source(buf.Bytes())
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "jsonschema-comments: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	src, err := generate("github.com/alecthomas/jsonschema/examples", "../../examples", "examples", "CommentMap")
+	require.NoError(t, err)
+
+	_, err = parser.ParseFile(token.NewFileSet(), "comments_gen.go", src, 0)
+	require.NoError(t, err)
+	require.Regexp(t, `"github.com/alecthomas/jsonschema/examples/nested.Pet.Name":\s+"Name of the animal.",`, string(src))
+}
+
+func TestGeneratedExamplesUpToDate(t *testing.T) {
+	src, err := generate("github.com/alecthomas/jsonschema/examples", "../../examples", "examples", "CommentMap")
+	require.NoError(t, err)
+
+	existing, err := ioutil.ReadFile("../../examples/comments_gen.go")
+	require.NoError(t, err)
+	require.Equal(t, string(existing), string(src), "examples/comments_gen.go is stale, run go generate ./examples")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/alecthomas/jsonschema"
+)
+
+// allowed lists the overall compatibilities accepted by each -require mode.
+var allowed = map[string][]jsonschema.Compatibility{
+	"none":     {jsonschema.Compatible, jsonschema.BackwardCompatible, jsonschema.ForwardCompatible},
+	"backward": {jsonschema.Compatible, jsonschema.BackwardCompatible},
+	"forward":  {jsonschema.Compatible, jsonschema.ForwardCompatible},
+	"full":     {jsonschema.Compatible},
+}
+
+// diff prints the changes between two schema files, failing if they are
+// breaking or don't have the compatibility required.
+func diff(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	require := flags.String("require", "none", "compatibility required of the new schema: none (only reject breaking changes), backward, forward or full")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: jsonschema diff [-require none|backward|forward|full] <old.json> <new.json>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	accepted, ok := allowed[*require]
+	if flags.NArg() != 2 || !ok {
+		flags.Usage()
+		return 2
+	}
+
+	before, err := loadSchema(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "jsonschema: %s\n", err)
+		return 1
+	}
+	after, err := loadSchema(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintf(stderr, "jsonschema: %s\n", err)
+		return 1
+	}
+
+	changes := jsonschema.Diff(before, after)
+	for _, c := range changes {
+		fmt.Fprintln(stdout, c)
+	}
+	summary := jsonschema.Summarize(changes)
+	fmt.Fprintf(stdout, "overall: %s\n", summary)
+	for _, c := range accepted {
+		if c == summary {
+			return 0
+		}
+	}
+	return 1
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/alecthomas/jsonschema"
+)
+
+// reflectProgram is run to reflect a type given by -type, which can only be
+// done by a program that imports its package.
+var reflectProgram = template.Must(template.New("main").Parse(`package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/alecthomas/jsonschema"
+	target {{printf "%q" .Package}}
+)
+
+func main() {
+	if err := json.NewEncoder(os.Stdout).Encode(jsonschema.Reflect(&target.{{.Name}}{})); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+`))
+
+// doc renders documentation for a schema file, or for the schema reflected
+// from a Go type.
+func doc(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("doc", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "markdown", "output format: markdown or html")
+	typeName := flags.String("type", "", "reflect the schema of a Go type, given as <import path>.<name>, instead of reading a schema file")
+	output := flags.String("o", "", "write to this file rather than standard output")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: jsonschema doc [-format markdown|html] [-o <file>] (<schema.json> | -type <import path>.<name>)")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	write, ok := map[string]func(io.Writer, *jsonschema.Schema) error{
+		"markdown": jsonschema.WriteMarkdown,
+		"html":     jsonschema.WriteHTML,
+	}[*format]
+	if !ok || (flags.NArg() == 1) == (*typeName != "") || flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	var (
+		s   *jsonschema.Schema
+		err error
+	)
+	if *typeName != "" {
+		s, err = reflectType(*typeName, stderr)
+	} else {
+		s, err = loadSchema(flags.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "jsonschema: %s\n", err)
+		return 1
+	}
+
+	b := &bytes.Buffer{}
+	if err := write(b, s); err != nil {
+		fmt.Fprintf(stderr, "jsonschema: %s\n", err)
+		return 1
+	}
+	if *output != "" {
+		err = ioutil.WriteFile(*output, b.Bytes(), 0644)
+	} else {
+		_, err = stdout.Write(b.Bytes())
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "jsonschema: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// reflectType reflects the type named by typeName, such as
+// "example.com/config.Server", by running a program importing its package
+// with "go run". The package must be resolvable from the module in the
+// working directory.
+func reflectType(typeName string, stderr io.Writer) (*jsonschema.Schema, error) {
+	dot := strings.LastIndex(typeName, ".")
+	if dot <= strings.LastIndex(typeName, "/") || dot == len(typeName)-1 {
+		return nil, fmt.Errorf("invalid type %q, expected <import path>.<name>", typeName)
+	}
+
+	dir, err := ioutil.TempDir("", "jsonschema-doc")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	src := &bytes.Buffer{}
+	err = reflectProgram.Execute(src, struct{ Package, Name string }{typeName[:dot], typeName[dot+1:]})
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(path, src.Bytes(), 0600); err != nil {
+		return nil, err
+	}
+
+	out := &bytes.Buffer{}
+	cmd := exec.Command("go", "run", path)
+	cmd.Stdout = out
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("reflecting %s: %w", typeName, err)
+	}
+	s := &jsonschema.Schema{}
+	if err := json.Unmarshal(out.Bytes(), s); err != nil {
+		return nil, fmt.Errorf("reflecting %s: %w", typeName, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,58 @@
+// Command jsonschema provides tools for working with JSON Schemas generated
+// by github.com/alecthomas/jsonschema.
+//
+// Usage:
+//
+//	jsonschema diff [-require none|backward|forward|full] <old.json> <new.json>
+//	jsonschema doc [-format markdown|html] [-o <file>] (<schema.json> | -type <import path>.<name>)
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/alecthomas/jsonschema"
+)
+
+const usage = `usage: jsonschema <command> [arguments]
+
+commands:
+  diff    report changes between two versions of a schema
+  doc     render documentation for a schema as Markdown or HTML
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command given by args, returning the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	switch args[0] {
+	case "diff":
+		return diff(args[1:], stdout, stderr)
+	case "doc":
+		return doc(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "jsonschema: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+}
+
+func loadSchema(path string) (*jsonschema.Schema, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &jsonschema.Schema{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeSchema(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	before := writeSchema(t, dir, "before.json", `{
+		"type": "object", "additionalProperties": false,
+		"properties": {"name": {"type": "string"}},
+		"required": ["name"]
+	}`)
+	added := writeSchema(t, dir, "added.json", `{
+		"type": "object", "additionalProperties": false,
+		"properties": {"name": {"type": "string"}, "age": {"type": "integer"}},
+		"required": ["name"]
+	}`)
+	required := writeSchema(t, dir, "required.json", `{
+		"type": "object", "additionalProperties": false,
+		"properties": {"name": {"type": "string"}, "age": {"type": "integer"}},
+		"required": ["name", "age"]
+	}`)
+	retyped := writeSchema(t, dir, "retyped.json", `{
+		"type": "object", "additionalProperties": false,
+		"properties": {"name": {"type": "integer"}},
+		"required": ["name"]
+	}`)
+
+	tests := []struct {
+		args   []string
+		status int
+		output string
+	}{
+		{[]string{"diff", before, before}, 0, "overall: compatible\n"},
+		{[]string{"diff", before, added}, 0, "backward compatible: #: property-added \"age\"\noverall: backward compatible\n"},
+		{[]string{"diff", "-require", "forward", before, added}, 1, "backward compatible: #: property-added \"age\"\noverall: backward compatible\n"},
+		{[]string{"diff", "-require", "forward", added, required}, 0, "forward compatible: #: required-added \"age\"\noverall: forward compatible\n"},
+		{[]string{"diff", "-require", "full", added, required}, 1, "forward compatible: #: required-added \"age\"\noverall: forward compatible\n"},
+		{[]string{"diff", before, retyped}, 1, "breaking: #/properties/name: type-changed \"type\" from \"string\" to \"integer\"\noverall: breaking\n"},
+	}
+	for _, test := range tests {
+		t.Run(filepath.Base(test.args[len(test.args)-1]), func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			require.Equal(t, test.status, run(test.args, stdout, stderr), stderr.String())
+			require.Equal(t, test.output, stdout.String())
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	require.Equal(t, 2, run(nil, stdout, stderr))
+	require.Equal(t, 2, run([]string{"frobnicate"}, stdout, stderr))
+	require.Equal(t, 2, run([]string{"diff", "only-one.json"}, stdout, stderr))
+	require.Equal(t, 2, run([]string{"diff", "-require", "sideways", "a.json", "b.json"}, stdout, stderr))
+	require.Equal(t, 1, run([]string{"diff", "missing.json", "missing.json"}, stdout, stderr))
+	require.Equal(t, 2, run([]string{"doc"}, stdout, stderr))
+	require.Equal(t, 2, run([]string{"doc", "-format", "pdf", "a.json"}, stdout, stderr))
+	require.Equal(t, 2, run([]string{"doc", "-type", "example.com/pkg.Type", "a.json"}, stdout, stderr))
+	require.Equal(t, 1, run([]string{"doc", "-type", "NoPackage"}, stdout, stderr))
+	require.Empty(t, stdout.String())
+}
+
+func TestDoc(t *testing.T) {
+	dir := t.TempDir()
+	schema := writeSchema(t, dir, "schema.json", `{
+		"$ref": "#/definitions/Server",
+		"definitions": {
+			"Server": {
+				"type": "object",
+				"properties": {"port": {"type": "integer", "default": 80}},
+				"required": ["port"]
+			}
+		}
+	}`)
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	require.Equal(t, 0, run([]string{"doc", schema}, stdout, stderr), stderr.String())
+	require.Contains(t, stdout.String(), "## Server\n")
+	require.Contains(t, stdout.String(), "| `port` | integer | yes | `80` |")
+
+	output := filepath.Join(dir, "schema.html")
+	stdout.Reset()
+	require.Equal(t, 0, run([]string{"doc", "-format", "html", "-o", output, schema}, stdout, stderr), stderr.String())
+	require.Empty(t, stdout.String())
+	html, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	require.Contains(t, string(html), `<section id="server">`)
+}
+
+func TestDocType(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the go command")
+	}
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	require.Equal(t, 0, run([]string{"doc", "-type", "github.com/alecthomas/jsonschema/examples.User"}, stdout, stderr), stderr.String())
+	require.Contains(t, stdout.String(), "# User\n")
+	require.Contains(t, stdout.String(), "## Pet\n")
+}
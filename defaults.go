@@ -0,0 +1,364 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// ReflectWithDefaults reflects v using the default Reflector, taking the
+// defaults of its properties from v. See Reflector.ReflectWithDefaults.
+func ReflectWithDefaults(v interface{}) *Schema {
+	return defaultReflector.ReflectWithDefaults(v)
+}
+
+// ReflectWithDefaults reflects v, setting the default of the property of
+// every non-zero field of v to the value of that field. This allows defaults
+// to be given by a populated value rather than by tags, e.g.
+// ReflectWithDefaults(DefaultConfig()). Types can also provide such a value
+// for every schema they are reflected into by implementing
+//
+//	JSONSchemaDefaults() interface{}
+//
+// Nested structs, and pointers to them, aren't defaults themselves: their
+// own non-zero fields become defaults in their definitions instead. As
+// definitions are shared by every property of their type, a definition
+// reached more than once within v takes the defaults of the first value
+// found. Other values, such as slices and maps, are used whole.
+//
+// It panics if a field can't be marshalled to JSON.
+func (r *Reflector) ReflectWithDefaults(v interface{}) *Schema {
+	s := r.Reflect(v)
+	r.defaultsFromValue(s, s.Type, v)
+	return s
+}
+
+// defaultsFromValue sets the defaults of t, a schema within s, to the
+// non-zero fields of v.
+func (r *Reflector) defaultsFromValue(s *Schema, t *Type, v interface{}) {
+	d := &defaulter{r: r, schema: s}
+	d.extract(reflect.ValueOf(v), t, map[*Type]bool{})
+}
+
+func (d *defaulter) extract(v reflect.Value, t *Type, visited map[*Type]bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if t = d.resolve(t); v.Kind() != reflect.Struct || t == nil || t.Properties == nil || visited[t] {
+		return
+	}
+	visited[t] = true
+	d.extractStruct(v, t, visited)
+}
+
+func (d *defaulter) extractStruct(v reflect.Value, t *Type, visited map[*Type]bool) {
+	st := v.Type()
+	for _, f := range d.r.structFields(st) {
+		fv, ok := f.fieldValue(v)
+		if f.remain || !ok {
+			continue
+		}
+
+		p, ok := t.Properties.Get(f.name)
+		property, _ := p.(*Type)
+		if !ok || property == nil || !fv.CanInterface() || fv.IsZero() {
+			continue
+		}
+		if nested := d.resolve(property); nested != nil && nested.Properties != nil && isStruct(fv.Type()) {
+			d.extract(fv, nested, visited)
+			continue
+		}
+		property.Default = jsonValue(fv.Interface(), st, f.StructField)
+		if f.quoted {
+			property.Default = quoteValue(property.Default)
+		}
+	}
+}
+
+func isStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// jsonValue returns v, the value of the field f of st, as decoded JSON.
+func jsonValue(v interface{}, st reflect.Type, f reflect.StructField) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("jsonschema: default of %s.%s: %s", st, f.Name, err))
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var d interface{}
+	if err := dec.Decode(&d); err != nil {
+		panic(fmt.Sprintf("jsonschema: default of %s.%s: %s", st, f.Name, err))
+	}
+	return d
+}
+
+// ApplyDefaults sets the zero-valued fields of the struct v points to, and of
+// the structs nested within it, to the defaults of their properties in the
+// schema reflected from it by the default Reflector. See
+// Reflector.ApplyDefaults.
+func ApplyDefaults(v interface{}) error {
+	return defaultReflector.ApplyDefaults(v)
+}
+
+// ApplyDefaults sets the zero-valued fields of the struct v points to, and of
+// the structs nested within it, to the defaults of their properties in the
+// schema reflected from it. Fields are reached through nested structs,
+// non-nil pointers, and the elements of slices, arrays and maps.
+//
+// Defaults are converted to the type of their field as if they were decoded
+// from JSON, with string defaults parsed as JSON when the property isn't a
+// string, as schemas from custom types may give them. An error is returned
+// if a default cannot be converted.
+func (r *Reflector) ApplyDefaults(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("jsonschema: cannot apply defaults to non-pointer %T", v)
+	}
+	d := &defaulter{r: r, schema: r.ReflectFromType(rv.Type())}
+	return d.apply(rv.Elem(), d.schema.Type)
+}
+
+type defaulter struct {
+	r      *Reflector
+	schema *Schema
+}
+
+// resolve returns the schema describing the values of t, following
+// references and looking through nullable alternatives.
+func (d *defaulter) resolve(t *Type) *Type {
+	t = resolveRef(d.schema, t)
+	if t == nil || t.Type != "" || t.Properties != nil {
+		return t
+	}
+	var alternatives []*Type
+	for _, s := range append(t.AnyOf[:len(t.AnyOf):len(t.AnyOf)], t.OneOf...) {
+		if s = resolveRef(d.schema, s); s != nil && s.Type != "null" {
+			alternatives = append(alternatives, s)
+		}
+	}
+	if len(alternatives) == 1 {
+		return alternatives[0]
+	}
+	return t
+}
+
+// defaultOf returns the default of t, which may be given alongside a
+// reference or within a nullable alternative rather than by the schema
+// these lead to.
+func (d *defaulter) defaultOf(t *Type) interface{} {
+	for i := 0; t != nil && i < 32; i++ {
+		if t.Default != nil {
+			return t.Default
+		}
+		next := resolveRef(d.schema, t)
+		if next == t {
+			next = d.resolve(t)
+		}
+		if next == t {
+			break
+		}
+		t = next
+	}
+	return nil
+}
+
+func (d *defaulter) apply(v reflect.Value, t *Type) error {
+	if t = d.resolve(t); t == nil {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return d.apply(v.Elem(), t)
+
+	case reflect.Struct:
+		return d.applyStruct(v, t)
+
+	case reflect.Slice, reflect.Array:
+		if t.Items == nil {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := d.apply(v.Index(i), t.Items); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		elem := mapValueSchema(t)
+		if elem == nil {
+			return nil
+		}
+		// Map elements can't be modified in place, so are copied and stored
+		// again.
+		iter := v.MapRange()
+		for iter.Next() {
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(iter.Value())
+			if err := d.apply(e, elem); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), e)
+		}
+	}
+	return nil
+}
+
+func (d *defaulter) applyStruct(v reflect.Value, t *Type) error {
+	if t.Properties == nil {
+		return nil
+	}
+	st := v.Type()
+	for _, f := range d.r.structFields(st) {
+		fv, ok := f.fieldValue(v)
+		if f.remain || !ok {
+			continue
+		}
+
+		p, ok := t.Properties.Get(f.name)
+		property, _ := p.(*Type)
+		if !ok || property == nil || !fv.CanSet() {
+			continue
+		}
+		if def := d.defaultOf(property); def != nil && fv.IsZero() {
+			dv, err := convertDefault(def, d.resolve(property), f.Type, f.quoted)
+			if err != nil {
+				return fmt.Errorf("jsonschema: default of %s.%s: %w", st, f.Name, err)
+			}
+			fv.Set(dv)
+		}
+		if err := d.apply(fv, property); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapValueSchema returns the schema of the values of a map, if they all share
+// one.
+func mapValueSchema(t *Type) *Type {
+	if len(t.PatternProperties) == 1 {
+		for _, s := range t.PatternProperties {
+			return s
+		}
+	}
+	return additionalPropertiesSchema(t)
+}
+
+// convertDefault converts the default def of the schema t to a value of type
+// typ, by way of JSON. Defaults of quoted fields are strings holding the JSON
+// of their values.
+func convertDefault(def interface{}, t *Type, typ reflect.Type, quoted bool) (reflect.Value, error) {
+	b, err := json.Marshal(typedDefault(def, t))
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if s, ok := def.(string); ok && quoted {
+		b = []byte(s)
+	}
+	v := reflect.New(typ)
+	if err := json.Unmarshal(b, v.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return v.Elem(), nil
+}
+
+// typedDefault returns def as a value of the type of t. Defaults are given as
+// strings by tags whatever their type, so strings are parsed as JSON unless t
+// is a string, including the items of arrays.
+func typedDefault(def interface{}, t *Type) interface{} {
+	if t == nil {
+		return def
+	}
+	switch d := def.(type) {
+	case string:
+		if t.Type == "string" || t.Type == "" {
+			return d
+		}
+		var v interface{}
+		dec := json.NewDecoder(bytes.NewReader([]byte(d)))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err == nil && !dec.More() {
+			return v
+		}
+	case []interface{}:
+		if t.Items == nil {
+			return d
+		}
+		items := make([]interface{}, len(d))
+		for i, item := range d {
+			items[i] = typedDefault(item, t.Items)
+		}
+		return items
+	}
+	return def
+}
+
+// ApplyDefaultsJSON returns a copy of the JSON document data in which the
+// properties missing from its objects are set to their defaults in s,
+// typed as described for Reflector.ApplyDefaults. Objects are reached
+// through the properties, patternProperties, additionalProperties and items
+// of their schemas. Properties of the result are sorted.
+func ApplyDefaultsJSON(s *Schema, data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	d := &defaulter{schema: s}
+	return json.Marshal(d.applyJSON(v, s.Type))
+}
+
+// UnmarshalWithDefaults decodes the JSON document data into v, after setting
+// the properties missing from it to their defaults in the schema reflected
+// from v. Unlike ApplyDefaults, explicit zero values in data are kept.
+func (r *Reflector) UnmarshalWithDefaults(data []byte, v interface{}) error {
+	data, err := ApplyDefaultsJSON(r.ReflectFromType(reflect.TypeOf(v)), data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (d *defaulter) applyJSON(v interface{}, t *Type) interface{} {
+	if t = d.resolve(t); t == nil {
+		return v
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		properties := propertyMap(t)
+		for _, name := range propertyNames(t) {
+			if _, ok := v[name]; ok {
+				continue
+			}
+			if def := d.defaultOf(properties[name]); def != nil {
+				v[name] = typedDefault(def, d.resolve(properties[name]))
+			}
+		}
+		elem := mapValueSchema(t)
+		for name, value := range v {
+			if p, ok := properties[name]; ok {
+				v[name] = d.applyJSON(value, p)
+			} else if elem != nil {
+				v[name] = d.applyJSON(value, elem)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = d.applyJSON(v[i], t.Items)
+		}
+	}
+	return v
+}
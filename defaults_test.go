@@ -0,0 +1,218 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type DefaultsLimits struct {
+	Retries int      `json:"retries" jsonschema:"default=3"`
+	Hosts   []string `json:"hosts,omitempty" jsonschema:"default=a,default=b"`
+	Ports   []int    `json:"ports,omitempty" jsonschema:"default=80,default=443"`
+}
+
+type DefaultsEmbedded struct {
+	Region string `json:"region" jsonschema:"default=eu"`
+}
+
+type DefaultsConfig struct {
+	DefaultsEmbedded
+	Name     string                    `json:"name" jsonschema:"default=service"`
+	Mode     *string                   `json:"mode,omitempty" jsonschema:"default=fast,nullable"`
+	Limits   DefaultsLimits            `json:"limits"`
+	Optional *DefaultsLimits           `json:"optional,omitempty"`
+	List     []DefaultsLimits          `json:"list,omitempty"`
+	ByName   map[string]DefaultsLimits `json:"by_name,omitempty"`
+	Ignored  string                    `json:"-" jsonschema:"default=ignored"`
+}
+
+func TestApplyDefaults(t *testing.T) {
+	c := &DefaultsConfig{
+		Name:   "custom",
+		List:   []DefaultsLimits{{Retries: 1}, {}},
+		ByName: map[string]DefaultsLimits{"x": {Hosts: []string{"c"}}},
+	}
+	require.NoError(t, ApplyDefaults(c))
+
+	fast := "fast"
+	limits := DefaultsLimits{Retries: 3, Hosts: []string{"a", "b"}, Ports: []int{80, 443}}
+	require.Equal(t, &DefaultsConfig{
+		DefaultsEmbedded: DefaultsEmbedded{Region: "eu"},
+		Name:             "custom",
+		Mode:             &fast,
+		Limits:           limits,
+		List: []DefaultsLimits{
+			{Retries: 1, Hosts: []string{"a", "b"}, Ports: []int{80, 443}},
+			limits,
+		},
+		ByName: map[string]DefaultsLimits{
+			"x": {Retries: 3, Hosts: []string{"c"}, Ports: []int{80, 443}},
+		},
+	}, c)
+
+	// Applying defaults again changes nothing.
+	again := *c
+	require.NoError(t, ApplyDefaults(&again))
+	require.Equal(t, c, &again)
+}
+
+func TestApplyDefaultsErrors(t *testing.T) {
+	require.Error(t, ApplyDefaults(DefaultsConfig{}))
+	require.Error(t, ApplyDefaults((*DefaultsConfig)(nil)))
+
+	type BadDefault struct {
+		Count CustomTime `json:"count"`
+	}
+	r := &Reflector{
+		TypeMapper: func(t reflect.Type) *Type {
+			if t == reflect.TypeOf(CustomTime{}) {
+				return &Type{Type: "integer", Default: "many"}
+			}
+			return nil
+		},
+	}
+	err := r.ApplyDefaults(&BadDefault{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "default of jsonschema.BadDefault.Count")
+}
+
+func TestApplyDefaultsJSON(t *testing.T) {
+	s := Reflect(&DefaultsConfig{})
+	actual, err := ApplyDefaultsJSON(s, []byte(`{
+		"name": "",
+		"limits": {"retries": 0},
+		"list": [{"hosts": []}],
+		"by_name": {"x": {}}
+	}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"region": "eu",
+		"name": "",
+		"mode": "fast",
+		"limits": {"retries": 0, "hosts": ["a", "b"], "ports": [80, 443]},
+		"list": [{"retries": 3, "hosts": [], "ports": [80, 443]}],
+		"by_name": {"x": {"retries": 3, "hosts": ["a", "b"], "ports": [80, 443]}}
+	}`, string(actual))
+
+	_, err = ApplyDefaultsJSON(s, []byte(`{`))
+	require.Error(t, err)
+}
+
+func TestUnmarshalWithDefaults(t *testing.T) {
+	var c DefaultsConfig
+	r := &Reflector{}
+	require.NoError(t, r.UnmarshalWithDefaults([]byte(`{"limits": {"retries": 0}}`), &c))
+	require.Equal(t, "service", c.Name)
+	require.Equal(t, "eu", c.Region)
+	require.Equal(t, 0, c.Limits.Retries)
+	require.Equal(t, []int{80, 443}, c.Limits.Ports)
+	require.Nil(t, c.Optional)
+}
+
+type ServerDefaults struct {
+	Host    string        `json:"host"`
+	Port    int           `json:"port" jsonschema:"default=80"`
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+type AppDefaults struct {
+	DefaultsEmbedded
+	Name     string            `json:"name"`
+	Debug    bool              `json:"debug,omitempty"`
+	Server   ServerDefaults    `json:"server"`
+	Backup   *ServerDefaults   `json:"backup,omitempty"`
+	Peers    []ServerDefaults  `json:"peers,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Started  time.Time         `json:"started,omitempty"`
+	Mode     *string           `json:"mode,omitempty" jsonschema:"nullable"`
+	Ratio    float64           `json:"ratio,omitempty"`
+	Disabled bool              `json:"disabled,omitempty"`
+}
+
+func defaultApp() *AppDefaults {
+	mode := "fast"
+	return &AppDefaults{
+		DefaultsEmbedded: DefaultsEmbedded{Region: "us"},
+		Name:             "app",
+		Debug:            true,
+		Server:           ServerDefaults{Host: "localhost", Port: 8080, Timeout: time.Second},
+		Backup:           &ServerDefaults{Host: "backup"},
+		Peers:            []ServerDefaults{{Host: "peer"}},
+		Tags:             []string{"a", "b"},
+		Labels:           map[string]string{"env": "dev"},
+		Started:          time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+		Mode:             &mode,
+		Ratio:            0.5,
+	}
+}
+
+// propertyDefaults returns the defaults of the properties of t.
+func propertyDefaults(t *testing.T, s *Schema, name string) string {
+	t.Helper()
+	defaults := map[string]interface{}{}
+	for property, p := range propertyMap(s.Definitions[name]) {
+		if p.Default != nil {
+			defaults[property] = p.Default
+		}
+	}
+	b, err := json.Marshal(defaults)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestReflectWithDefaults(t *testing.T) {
+	s := ReflectWithDefaults(defaultApp())
+	require.JSONEq(t, `{
+		"region": "us",
+		"name": "app",
+		"debug": true,
+		"peers": [{"host": "peer", "port": 0}],
+		"tags": ["a", "b"],
+		"labels": {"env": "dev"},
+		"started": "2006-01-02T15:04:05Z",
+		"mode": "fast",
+		"ratio": 0.5
+	}`, propertyDefaults(t, s, "AppDefaults"))
+	// The server comes before the backup, so its defaults are used.
+	require.JSONEq(t, `{"host": "localhost", "port": 8080, "timeout": 1000000000}`, propertyDefaults(t, s, "ServerDefaults"))
+
+	// Without a value, only the defaults from tags remain.
+	s = Reflect(&AppDefaults{})
+	require.JSONEq(t, `{"region": "eu"}`, propertyDefaults(t, s, "AppDefaults"))
+	require.JSONEq(t, `{"port": 80}`, propertyDefaults(t, s, "ServerDefaults"))
+}
+
+type ProvidedDefaults struct {
+	Server ServerDefaults `json:"server"`
+	Level  int            `json:"level"`
+}
+
+func (ProvidedDefaults) JSONSchemaDefaults() interface{} {
+	return ProvidedDefaults{Server: ServerDefaults{Host: "provided"}, Level: 3}
+}
+
+type UsesProvidedDefaults struct {
+	Provided ProvidedDefaults `json:"provided"`
+}
+
+func TestJSONSchemaDefaults(t *testing.T) {
+	s := Reflect(&UsesProvidedDefaults{})
+	require.JSONEq(t, `{"level": 3}`, propertyDefaults(t, s, "ProvidedDefaults"))
+	require.JSONEq(t, `{"host": "provided", "port": 80}`, propertyDefaults(t, s, "ServerDefaults"))
+
+	var v UsesProvidedDefaults
+	require.NoError(t, ApplyDefaults(&v))
+	require.Equal(t, ProvidedDefaults{Server: ServerDefaults{Host: "provided", Port: 80}, Level: 3}, v.Provided)
+}
+
+func TestJSONSchemaDefaultsExpanded(t *testing.T) {
+	s := (&Reflector{ExpandedStruct: true}).Reflect(&ProvidedDefaults{})
+	level, _ := s.Properties.Get("level")
+	require.Equal(t, json.Number("3"), level.(*Type).Default)
+	require.JSONEq(t, `{"host": "provided", "port": 80}`, propertyDefaults(t, s, "ServerDefaults"))
+}
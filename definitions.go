@@ -0,0 +1,238 @@
+package jsonschema
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// definitionSet holds the definitions collected while reflecting, along with
+// the Go type each of them was reflected from so that different types
+// sharing the same name can be detected.
+type definitionSet struct {
+	definitions Definitions
+	types       map[string]reflect.Type
+	collisions  []*NameCollisionError
+	// invalidDefaults holds the fields whose defaults couldn't be parsed.
+	invalidDefaults []*InvalidDefaultError
+
+	typeName func(reflect.Type) string
+	// names overrides typeName for types whose names have been disambiguated.
+	names map[reflect.Type]string
+
+	// cache holds the schemas of struct types, or is nil if they aren't
+	// cached. While the fields of a struct to be cached are reflected, deps
+	// records the types they reflect.
+	cache *reflectCache
+	deps  *[]reflect.Type
+}
+
+func newDefinitionSet(typeName func(reflect.Type) string) *definitionSet {
+	return &definitionSet{
+		definitions: Definitions{},
+		types:       map[string]reflect.Type{},
+		typeName:    typeName,
+	}
+}
+
+// name returns the definition name of t.
+func (d *definitionSet) name(t reflect.Type) string {
+	if name, ok := d.names[t]; ok {
+		return name
+	}
+	return d.typeName(t)
+}
+
+// ref returns the reference to the definition of t.
+func (d *definitionSet) ref(t reflect.Type) string {
+	return "#/definitions/" + d.name(t)
+}
+
+// get returns the definition registered under the name of t, recording a
+// collision if it was reflected from another type.
+func (d *definitionSet) get(t reflect.Type) (*Type, bool) {
+	name := d.name(t)
+	st, ok := d.definitions[name]
+	if ok {
+		d.checkOwner(name, t)
+	}
+	return st, ok
+}
+
+// add registers st as the definition of t. An existing definition of the
+// same name is replaced, and recorded as a collision if it belongs to
+// another type.
+func (d *definitionSet) add(t reflect.Type, st *Type) {
+	name := d.name(t)
+	d.checkOwner(name, t)
+	d.definitions[name] = st
+	d.types[name] = t
+}
+
+func (d *definitionSet) remove(t reflect.Type) {
+	name := d.name(t)
+	delete(d.definitions, name)
+	delete(d.types, name)
+}
+
+func (d *definitionSet) checkOwner(name string, t reflect.Type) {
+	// Anonymous types all share the empty name, which has always been
+	// tolerated, so only named types are checked.
+	if name == "" {
+		return
+	}
+	if owner, ok := d.types[name]; ok && owner != t {
+		d.collisions = append(d.collisions, &NameCollisionError{Name: name, Types: []reflect.Type{owner, t}})
+	}
+}
+
+// invalidDefault records that the default of a field of t couldn't be parsed.
+func (d *definitionSet) invalidDefault(t reflect.Type, err *InvalidDefaultError) {
+	err.Type = t
+	d.invalidDefaults = append(d.invalidDefaults, err)
+}
+
+// err returns the first name collision, or failing that the first invalid
+// default, found while reflecting.
+func (d *definitionSet) err() error {
+	if len(d.collisions) > 0 {
+		return d.collisions[0]
+	}
+	if len(d.invalidDefaults) > 0 {
+		return d.invalidDefaults[0]
+	}
+	return nil
+}
+
+// A NameCollisionError reports that two different Go types were reflected to
+// the same definition name.
+type NameCollisionError struct {
+	Name  string
+	Types []reflect.Type
+}
+
+func (e *NameCollisionError) Error() string {
+	names := make([]string, len(e.Types))
+	for i, t := range e.Types {
+		names[i] = fullyQualifiedTypeName(t)
+	}
+	return fmt.Sprintf("jsonschema: definition %q is used by more than one type: %s", e.Name, strings.Join(names, ", "))
+}
+
+// reflectDefinitions calls fn to reflect into a new definitionSet. When
+// DisambiguateTypeNames is set and fn caused collisions, the colliding types
+// are renamed and fn is called again with a fresh set, until no collisions
+// that can be resolved remain.
+func (r *Reflector) reflectDefinitions(fn func(definitions *definitionSet)) *definitionSet {
+	definitions := newDefinitionSet(r.typeName)
+	definitions.cache = r.structCache()
+	fn(definitions)
+	if !r.DisambiguateTypeNames {
+		return definitions
+	}
+
+	conflicting := map[string][]reflect.Type{}
+	for len(definitions.collisions) > 0 {
+		added := false
+		for _, c := range definitions.collisions {
+			for _, t := range c.Types {
+				name := r.typeName(t)
+				if !containsType(conflicting[name], t) {
+					conflicting[name] = append(conflicting[name], t)
+					added = true
+				}
+			}
+		}
+		if !added {
+			break
+		}
+		definitions = newDefinitionSet(r.typeName)
+		definitions.names = disambiguateTypeNames(conflicting)
+		fn(definitions)
+	}
+	return definitions
+}
+
+func containsType(ts []reflect.Type, t reflect.Type) bool {
+	for _, c := range ts {
+		if c == t {
+			return true
+		}
+	}
+	return false
+}
+
+// disambiguateTypeNames names each group of types sharing a name by prefixing
+// the name with the shortest suffix of their package paths that is unique
+// within the group. The result only depends on the types in each group, so
+// names are stable however the types are encountered.
+func disambiguateTypeNames(conflicting map[string][]reflect.Type) map[reflect.Type]string {
+	names := map[reflect.Type]string{}
+	for name, ts := range conflicting {
+		paths := make([][]string, len(ts))
+		longest := 0
+		for i, t := range ts {
+			paths[i] = strings.Split(t.PkgPath(), "/")
+			if len(paths[i]) > longest {
+				longest = len(paths[i])
+			}
+		}
+		for n := 1; n <= longest; n++ {
+			prefixes := make([]string, len(ts))
+			for i, path := range paths {
+				prefixes[i] = packagePrefix(path, n)
+			}
+			if !uniqueStrings(prefixes) {
+				continue
+			}
+			for i, t := range ts {
+				names[t] = prefixes[i] + upperFirst(name)
+			}
+			break
+		}
+	}
+	return names
+}
+
+// packagePrefix returns the last n elements of path joined in camel case,
+// e.g. "acmeDb" for "github.com/acme/db" and n = 2.
+func packagePrefix(path []string, n int) string {
+	if n > len(path) {
+		n = len(path)
+	}
+	words := strings.FieldsFunc(strings.Join(path[len(path)-n:], "/"), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i := range words {
+		if i == 0 {
+			words[i] = lowerFirst(words[i])
+		} else {
+			words[i] = upperFirst(words[i])
+		}
+	}
+	return strings.Join(words, "")
+}
+
+func upperFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
+
+func lowerFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[n:]
+}
+
+func uniqueStrings(ss []string) bool {
+	sorted := append([]string(nil), ss...)
+	sort.Strings(sorted)
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i] == sorted[i-1] {
+			return false
+		}
+	}
+	return true
+}
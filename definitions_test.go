@@ -0,0 +1,69 @@
+package jsonschema
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/jsonschema/examples"
+	"github.com/alecthomas/jsonschema/examples/animals"
+	"github.com/alecthomas/jsonschema/examples/nested"
+)
+
+func TestDisambiguateTypeNames(t *testing.T) {
+	r := &Reflector{DisambiguateTypeNames: true}
+	s := r.Reflect(&Household{})
+
+	require.Contains(t, s.Definitions, "animalsPet")
+	require.Contains(t, s.Definitions, "nestedPet")
+	require.NotContains(t, s.Definitions, "Pet")
+	require.Contains(t, s.Definitions, "Plant", "only conflicting names should change")
+
+	wild, _ := s.Definitions["Household"].Properties.Get("wild")
+	require.Equal(t, "#/definitions/animalsPet", wild.(*Type).Ref)
+	pets, _ := s.Definitions["User"].Properties.Get("pets")
+	require.Equal(t, "#/definitions/nestedPet", pets.(*Type).Items.Ref)
+}
+
+func TestReflectStrict(t *testing.T) {
+	_, err := (&Reflector{}).ReflectStrict(&Household{})
+	var collision *NameCollisionError
+	require.True(t, errors.As(err, &collision))
+	require.Equal(t, "Pet", collision.Name)
+
+	s, err := (&Reflector{DisambiguateTypeNames: true}).ReflectStrict(&Household{})
+	require.NoError(t, err)
+	require.Contains(t, s.Definitions, "animalsPet")
+}
+
+func TestDisambiguateTypeNamesSchemaSet(t *testing.T) {
+	set := (&Reflector{DisambiguateTypeNames: true}).NewSchemaSet()
+	require.NoError(t, set.Add(&nested.Pet{}))
+	require.Equal(t, []string{"Pet"}, set.Roots())
+
+	// Adding a conflicting type renames the existing definition as well.
+	require.NoError(t, set.Add(&animals.Pet{}))
+	require.NoError(t, set.Add(&examples.User{}))
+	require.Equal(t, []string{"nestedPet", "animalsPet", "User"}, set.Roots())
+	pets, _ := set.Bundle().Definitions["User"].Properties.Get("pets")
+	require.Equal(t, "#/definitions/nestedPet", pets.(*Type).Items.Ref)
+}
+
+func TestDisambiguatedNamesAreStable(t *testing.T) {
+	a := disambiguateTypeNames(map[string][]reflect.Type{
+		"Pet": {reflect.TypeOf(nested.Pet{}), reflect.TypeOf(animals.Pet{})},
+	})
+	b := disambiguateTypeNames(map[string][]reflect.Type{
+		"Pet": {reflect.TypeOf(animals.Pet{}), reflect.TypeOf(nested.Pet{})},
+	})
+	require.Equal(t, a, b)
+}
+
+func TestPackagePrefix(t *testing.T) {
+	path := []string{"github.com", "acme", "go-db"}
+	require.Equal(t, "goDb", packagePrefix(path, 1))
+	require.Equal(t, "acmeGoDb", packagePrefix(path, 2))
+	require.Equal(t, "githubComAcmeGoDb", packagePrefix(path, 5))
+}
@@ -0,0 +1,494 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Compatibility classifies a change between two versions of a schema by its
+// effect on which documents are valid.
+type Compatibility int
+
+const (
+	// Compatible changes don't affect which documents are valid.
+	Compatible Compatibility = iota
+	// BackwardCompatible changes loosen the schema: documents valid against
+	// the old schema remain valid against the new one, so consumers using
+	// the new schema can still read data produced with the old one.
+	BackwardCompatible
+	// ForwardCompatible changes tighten the schema: documents valid against
+	// the new schema are also valid against the old one, so consumers still
+	// using the old schema can read data produced with the new one.
+	ForwardCompatible
+	// Breaking changes are neither backward nor forward compatible.
+	Breaking
+)
+
+func (c Compatibility) String() string {
+	switch c {
+	case Compatible:
+		return "compatible"
+	case BackwardCompatible:
+		return "backward compatible"
+	case ForwardCompatible:
+		return "forward compatible"
+	default:
+		return "breaking"
+	}
+}
+
+// Combine returns the compatibility of applying both c and o.
+func (c Compatibility) Combine(o Compatibility) Compatibility {
+	switch {
+	case c == o || o == Compatible:
+		return c
+	case c == Compatible:
+		return o
+	default:
+		return Breaking
+	}
+}
+
+// ChangeKind identifies the kind of a Change.
+type ChangeKind string
+
+// Kinds of changes reported by Diff.
+const (
+	PropertyAdded               ChangeKind = "property-added"
+	PropertyRemoved             ChangeKind = "property-removed"
+	RequiredAdded               ChangeKind = "required-added"
+	RequiredRemoved             ChangeKind = "required-removed"
+	TypeChanged                 ChangeKind = "type-changed"
+	ConstraintChanged           ChangeKind = "constraint-changed"
+	EnumChanged                 ChangeKind = "enum-changed"
+	AdditionalPropertiesChanged ChangeKind = "additional-properties-changed"
+	SchemaChanged               ChangeKind = "schema-changed"
+)
+
+// A Change is a difference between two versions of a schema, as reported by
+// Diff.
+type Change struct {
+	// Path is a JSON Pointer to the changed schema, with references resolved
+	// so that it follows the structure of the documents being described,
+	// e.g. "/properties/address/properties/zip".
+	Path string
+	Kind ChangeKind
+	// Keyword is the changed keyword, or the property name for property and
+	// required changes.
+	Keyword       string
+	Old, New      interface{}
+	Compatibility Compatibility
+}
+
+func (c Change) String() string {
+	detail := fmt.Sprintf("%s %q", c.Kind, c.Keyword)
+	if c.Old != nil || c.New != nil {
+		detail += fmt.Sprintf(" from %s to %s", diffValue(c.Old), diffValue(c.New))
+	}
+	return fmt.Sprintf("%s: #%s: %s", c.Compatibility, c.Path, detail)
+}
+
+func diffValue(v interface{}) string {
+	if v == nil {
+		return "none"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// Summarize returns the overall compatibility of a set of changes. Mixing
+// backward and forward compatible changes is breaking.
+func Summarize(changes []Change) Compatibility {
+	c := Compatible
+	for _, change := range changes {
+		c = c.Combine(change.Compatibility)
+	}
+	return c
+}
+
+// Diff compares two versions of a schema, returning the changes that affect
+// which documents are valid. Annotations such as descriptions, titles and
+// defaults are ignored. References are resolved against the definitions of
+// each schema, so changes made to a definition are reported at every path it
+// is used from.
+//
+// Changes the classification doesn't understand, such as changes to the
+// members of "oneOf", are reported as Breaking.
+func Diff(before, after *Schema) []Change {
+	d := &differ{before: before, after: after, seen: map[[2]*Type]bool{}}
+	d.diff("", before.Type, after.Type)
+	return d.changes
+}
+
+type differ struct {
+	before, after *Schema
+	seen          map[[2]*Type]bool
+	changes       []Change
+}
+
+func (d *differ) add(path string, kind ChangeKind, keyword string, before, after interface{}, c Compatibility) {
+	d.changes = append(d.changes, Change{Path: path, Kind: kind, Keyword: keyword, Old: before, New: after, Compatibility: c})
+}
+
+func resolveRef(s *Schema, t *Type) *Type {
+	for i := 0; t != nil && t.Ref != "" && i < 32; i++ {
+		name := strings.TrimPrefix(t.Ref, definitionsPrefix)
+		def, ok := s.Definitions[name]
+		if !ok || name == t.Ref {
+			break
+		}
+		t = def
+	}
+	return t
+}
+
+func (d *differ) diff(path string, before, after *Type) {
+	before, after = resolveRef(d.before, before), resolveRef(d.after, after)
+	if before == nil || after == nil {
+		if before != after {
+			d.add(path, SchemaChanged, "", nil, nil, Breaking)
+		}
+		return
+	}
+	// Recursive types would otherwise be compared forever.
+	if d.seen[[2]*Type{before, after}] {
+		return
+	}
+	d.seen[[2]*Type{before, after}] = true
+
+	if before.Ref != after.Ref {
+		d.add(path, SchemaChanged, "$ref", before.Ref, after.Ref, Breaking)
+	}
+	d.diffType(path, before, after)
+	d.diffProperties(path, before, after)
+	d.diffAdditionalProperties(path, before, after)
+	d.diffEnum(path, before.Enum, after.Enum)
+
+	d.diffLowerBound(path, "minimum", before.Minimum, after.Minimum)
+	d.diffUpperBound(path, "maximum", before.Maximum, after.Maximum)
+	d.diffFlag(path, "exclusiveMinimum", before.ExclusiveMinimum, after.ExclusiveMinimum)
+	d.diffFlag(path, "exclusiveMaximum", before.ExclusiveMaximum, after.ExclusiveMaximum)
+	d.diffLowerBound(path, "minLength", before.MinLength, after.MinLength)
+	d.diffUpperBound(path, "maxLength", before.MaxLength, after.MaxLength)
+	d.diffLowerBound(path, "minItems", before.MinItems, after.MinItems)
+	d.diffUpperBound(path, "maxItems", before.MaxItems, after.MaxItems)
+	d.diffLowerBound(path, "minProperties", before.MinProperties, after.MinProperties)
+	d.diffUpperBound(path, "maxProperties", before.MaxProperties, after.MaxProperties)
+	d.diffFlag(path, "uniqueItems", before.UniqueItems, after.UniqueItems)
+	d.diffMultipleOf(path, before.MultipleOf, after.MultipleOf)
+	d.diffString(path, "pattern", before.Pattern, after.Pattern)
+	d.diffString(path, "format", before.Format, after.Format)
+
+	d.diff(path+"/items", before.Items, after.Items)
+	d.diff(path+"/additionalItems", before.AdditionalItems, after.AdditionalItems)
+	d.diffSchemaMap(path+"/patternProperties/", before.PatternProperties, after.PatternProperties)
+	d.diffSchemaList(path, "allOf", before.AllOf, after.AllOf)
+	d.diffSchemaList(path, "anyOf", before.AnyOf, after.AnyOf)
+	d.diffSchemaList(path, "oneOf", before.OneOf, after.OneOf)
+	d.diffOpaque(path, "not", before.Not, after.Not)
+	d.diffOpaque(path, "dependencies", before.Dependencies, after.Dependencies)
+}
+
+// diffType compares the types of schemas, which may be lists of types, where
+// allowing more types loosens the schema. Integers are numbers, so changing
+// "integer" to "number" loosens it too.
+func (d *differ) diffType(path string, before, after *Type) {
+	var c Compatibility
+	switch {
+	case before.Type == after.Type && strings.Join(before.AdditionalTypes, ",") == strings.Join(after.AdditionalTypes, ","):
+		return
+	case before.Type == "":
+		c = ForwardCompatible
+	case after.Type == "":
+		c = BackwardCompatible
+	default:
+		loosened, tightened := allowsTypes(after, before), allowsTypes(before, after)
+		switch {
+		case loosened && tightened:
+			return
+		case loosened:
+			c = BackwardCompatible
+		case tightened:
+			c = ForwardCompatible
+		default:
+			c = Breaking
+		}
+	}
+	d.add(path, TypeChanged, "type", typeValue(before), typeValue(after), c)
+}
+
+// allowsTypes returns whether t allows all the types of other.
+func allowsTypes(t, other *Type) bool {
+	for _, typ := range other.types() {
+		if !t.allowsType(typ) {
+			return false
+		}
+	}
+	return true
+}
+
+// typeValue returns the value of the type keyword of t, or nil if it has
+// none.
+func typeValue(t *Type) interface{} {
+	if len(t.AdditionalTypes) > 0 {
+		return t.types()
+	}
+	return emptyToNil(t.Type)
+}
+
+func (d *differ) diffProperties(path string, before, after *Type) {
+	beforeProps, afterProps := propertyMap(before), propertyMap(after)
+	for _, name := range propertyNames(before) {
+		if _, ok := afterProps[name]; !ok {
+			// A property that is no longer described is either no longer
+			// allowed, or no longer constrained.
+			c := BackwardCompatible
+			if !allowsAdditionalProperties(after) {
+				c = ForwardCompatible
+			}
+			d.add(path, PropertyRemoved, name, nil, nil, c)
+		}
+	}
+	for _, name := range propertyNames(after) {
+		p := path + "/properties/" + escapePointer(name)
+		if op, ok := beforeProps[name]; ok {
+			d.diff(p, op, afterProps[name])
+			continue
+		}
+		c := ForwardCompatible
+		if !allowsAdditionalProperties(before) {
+			c = BackwardCompatible
+		}
+		d.add(path, PropertyAdded, name, nil, nil, c)
+	}
+
+	beforeRequired, afterRequired := stringSet(before.Required), stringSet(after.Required)
+	for _, name := range after.Required {
+		if !beforeRequired[name] {
+			d.add(path, RequiredAdded, name, nil, nil, ForwardCompatible)
+		}
+	}
+	for _, name := range before.Required {
+		if !afterRequired[name] {
+			d.add(path, RequiredRemoved, name, nil, nil, BackwardCompatible)
+		}
+	}
+}
+
+func (d *differ) diffAdditionalProperties(path string, before, after *Type) {
+	beforeAllowed, afterAllowed := allowsAdditionalProperties(before), allowsAdditionalProperties(after)
+	switch {
+	case beforeAllowed && !afterAllowed:
+		d.add(path, AdditionalPropertiesChanged, "additionalProperties", true, false, ForwardCompatible)
+	case !beforeAllowed && afterAllowed:
+		d.add(path, AdditionalPropertiesChanged, "additionalProperties", false, true, BackwardCompatible)
+	case beforeAllowed && afterAllowed:
+		beforeSchema, afterSchema := additionalPropertiesSchema(before), additionalPropertiesSchema(after)
+		if beforeSchema != nil || afterSchema != nil {
+			if beforeSchema == nil {
+				beforeSchema = &Type{}
+			}
+			if afterSchema == nil {
+				afterSchema = &Type{}
+			}
+			d.diff(path+"/additionalProperties", beforeSchema, afterSchema)
+		}
+	}
+}
+
+func (d *differ) diffEnum(path string, before, after []interface{}) {
+	switch {
+	case len(before) == 0 && len(after) == 0:
+		return
+	case len(before) == 0:
+		d.add(path, EnumChanged, "enum", nil, after, ForwardCompatible)
+		return
+	case len(after) == 0:
+		d.add(path, EnumChanged, "enum", before, nil, BackwardCompatible)
+		return
+	}
+	beforeValues, afterValues := valueSet(before), valueSet(after)
+	var removed, added bool
+	for k := range beforeValues {
+		removed = removed || !afterValues[k]
+	}
+	for k := range afterValues {
+		added = added || !beforeValues[k]
+	}
+	c := Compatible
+	if removed {
+		c = c.Combine(ForwardCompatible)
+	}
+	if added {
+		c = c.Combine(BackwardCompatible)
+	}
+	if c != Compatible {
+		d.add(path, EnumChanged, "enum", before, after, c)
+	}
+}
+
+// diffLowerBound compares keywords such as minimum, where raising the bound
+// tightens the schema. Zero values are omitted from schemas, so are treated
+// as no bound: adding a bound tightens the schema and removing one loosens
+// it, even if the bound is negative.
+func (d *differ) diffLowerBound(path, keyword string, before, after int) {
+	switch {
+	case before == after:
+	case before == 0 || (after != 0 && after > before):
+		d.add(path, ConstraintChanged, keyword, zeroToNil(before), zeroToNil(after), ForwardCompatible)
+	default:
+		d.add(path, ConstraintChanged, keyword, zeroToNil(before), zeroToNil(after), BackwardCompatible)
+	}
+}
+
+// diffUpperBound compares keywords such as maximum, where lowering the bound
+// tightens the schema and zero means no bound.
+func (d *differ) diffUpperBound(path, keyword string, before, after int) {
+	switch {
+	case before == after:
+	case before == 0 || (after != 0 && after < before):
+		d.add(path, ConstraintChanged, keyword, zeroToNil(before), zeroToNil(after), ForwardCompatible)
+	default:
+		d.add(path, ConstraintChanged, keyword, zeroToNil(before), zeroToNil(after), BackwardCompatible)
+	}
+}
+
+// diffFlag compares boolean keywords which tighten the schema when set.
+func (d *differ) diffFlag(path, keyword string, before, after bool) {
+	switch {
+	case before == after:
+	case after:
+		d.add(path, ConstraintChanged, keyword, before, after, ForwardCompatible)
+	default:
+		d.add(path, ConstraintChanged, keyword, before, after, BackwardCompatible)
+	}
+}
+
+func (d *differ) diffMultipleOf(path string, before, after int) {
+	c := Breaking
+	switch {
+	case before == after:
+		return
+	case before == 0 || (after != 0 && after%before == 0):
+		c = ForwardCompatible
+	case after == 0 || before%after == 0:
+		c = BackwardCompatible
+	}
+	d.add(path, ConstraintChanged, "multipleOf", zeroToNil(before), zeroToNil(after), c)
+}
+
+// diffString compares keywords such as pattern, where any change other than
+// adding or removing the keyword is treated as breaking.
+func (d *differ) diffString(path, keyword, before, after string) {
+	c := Breaking
+	switch {
+	case before == after:
+		return
+	case before == "":
+		c = ForwardCompatible
+	case after == "":
+		c = BackwardCompatible
+	}
+	d.add(path, ConstraintChanged, keyword, emptyToNil(before), emptyToNil(after), c)
+}
+
+func (d *differ) diffSchemaMap(path string, before, after map[string]*Type) {
+	for _, k := range sortedDefinitionNames(before) {
+		if a, ok := after[k]; ok {
+			d.diff(path+escapePointer(k), before[k], a)
+		} else {
+			d.add(strings.TrimSuffix(path, "/"), SchemaChanged, k, before[k], nil, BackwardCompatible)
+		}
+	}
+	for _, k := range sortedDefinitionNames(after) {
+		if _, ok := before[k]; !ok {
+			d.add(strings.TrimSuffix(path, "/"), SchemaChanged, k, nil, after[k], ForwardCompatible)
+		}
+	}
+}
+
+// diffSchemaList compares the members of allOf, anyOf and oneOf one by one
+// when their number is unchanged, or reports them as breaking otherwise.
+func (d *differ) diffSchemaList(path, keyword string, before, after []*Type) {
+	if len(before) != len(after) {
+		d.add(path, SchemaChanged, keyword, before, after, Breaking)
+		return
+	}
+	for i := range before {
+		d.diff(fmt.Sprintf("%s/%s/%d", path, keyword, i), before[i], after[i])
+	}
+}
+
+// diffOpaque reports any change to keywords that aren't compared in detail
+// as breaking.
+func (d *differ) diffOpaque(path, keyword string, before, after interface{}) {
+	if diffValue(before) != diffValue(after) {
+		d.add(path, SchemaChanged, keyword, before, after, Breaking)
+	}
+}
+
+func allowsAdditionalProperties(t *Type) bool {
+	return strings.TrimSpace(string(t.AdditionalProperties)) != "false"
+}
+
+func additionalPropertiesSchema(t *Type) *Type {
+	var s *Type
+	if err := json.Unmarshal(t.AdditionalProperties, &s); err != nil {
+		return nil
+	}
+	return s
+}
+
+func propertyNames(t *Type) []string {
+	if t.Properties == nil {
+		return nil
+	}
+	return t.Properties.Keys()
+}
+
+func propertyMap(t *Type) map[string]*Type {
+	m := map[string]*Type{}
+	for _, name := range propertyNames(t) {
+		v, _ := t.Properties.Get(name)
+		if p, ok := v.(*Type); ok {
+			m[name] = p
+		}
+	}
+	return m
+}
+
+func stringSet(ss []string) map[string]bool {
+	m := make(map[string]bool, len(ss))
+	for _, s := range ss {
+		m[s] = true
+	}
+	return m
+}
+
+// valueSet indexes JSON values by their encoding, so that for example the
+// integer 1 and the float 1.0 of a loaded schema are the same.
+func valueSet(vs []interface{}) map[string]bool {
+	m := make(map[string]bool, len(vs))
+	for _, v := range vs {
+		m[diffValue(v)] = true
+	}
+	return m
+}
+
+func zeroToNil(i int) interface{} {
+	if i == 0 {
+		return nil
+	}
+	return i
+}
+
+func emptyToNil(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
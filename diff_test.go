@@ -0,0 +1,83 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type PayloadV1 struct {
+	Name     string   `json:"name" jsonschema:"maxLength=20"`
+	Age      int      `json:"age,omitempty" jsonschema:"minimum=1"`
+	Color    string   `json:"color" jsonschema:"enum=red,enum=green"`
+	Nickname string   `json:"nickname,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Score    int      `json:"score"`
+}
+
+type PayloadV2 struct {
+	Name  string   `json:"name" jsonschema:"maxLength=10"`
+	Age   int      `json:"age" jsonschema:"minimum=1"`
+	Color string   `json:"color" jsonschema:"enum=red,enum=green,enum=blue"`
+	Tags  []int    `json:"tags,omitempty"`
+	Score float64  `json:"score"`
+	Email string   `json:"email,omitempty"`
+	Extra []string `json:"extra,omitempty"`
+}
+
+func TestDiff(t *testing.T) {
+	changes := Diff(Reflect(&PayloadV1{}), Reflect(&PayloadV2{}))
+
+	var actual []string
+	for _, c := range changes {
+		actual = append(actual, c.String())
+	}
+	require.Equal(t, []string{
+		`forward compatible: #: property-removed "nickname"`,
+		`forward compatible: #/properties/name: constraint-changed "maxLength" from 20 to 10`,
+		`backward compatible: #/properties/color: enum-changed "enum" from ["red","green"] to ["red","green","blue"]`,
+		`breaking: #/properties/tags/items: type-changed "type" from "string" to "integer"`,
+		`backward compatible: #/properties/score: type-changed "type" from "integer" to "number"`,
+		`backward compatible: #: property-added "email"`,
+		`backward compatible: #: property-added "extra"`,
+		`forward compatible: #: required-added "age"`,
+	}, actual)
+	require.Equal(t, Breaking, Summarize(changes))
+}
+
+func TestDiffAdditionalProperties(t *testing.T) {
+	before := Reflect(&PayloadV1{})
+	after := (&Reflector{AllowAdditionalProperties: true}).Reflect(&PayloadV1{})
+
+	changes := Diff(before, after)
+	require.Len(t, changes, 1)
+	require.Equal(t, AdditionalPropertiesChanged, changes[0].Kind)
+	require.Equal(t, BackwardCompatible, Summarize(changes))
+
+	changes = Diff(after, before)
+	require.Equal(t, ForwardCompatible, Summarize(changes))
+
+	require.Empty(t, Diff(before, Reflect(&PayloadV1{})))
+	require.Empty(t, Diff(Reflect(&TreeNode{}), Reflect(&TreeNode{})))
+}
+
+func TestDiffNegativeBounds(t *testing.T) {
+	bounded := &Schema{Type: &Type{Type: "integer", Minimum: -5, Maximum: -1}}
+	unbounded := &Schema{Type: &Type{Type: "integer"}}
+
+	changes := Diff(bounded, unbounded)
+	require.Len(t, changes, 2)
+	require.Equal(t, `backward compatible: #: constraint-changed "minimum" from -5 to none`, changes[0].String())
+	require.Equal(t, `backward compatible: #: constraint-changed "maximum" from -1 to none`, changes[1].String())
+
+	changes = Diff(unbounded, bounded)
+	require.Equal(t, ForwardCompatible, Summarize(changes))
+}
+
+func TestCompatibilityCombine(t *testing.T) {
+	require.Equal(t, Compatible, Compatible.Combine(Compatible))
+	require.Equal(t, ForwardCompatible, Compatible.Combine(ForwardCompatible))
+	require.Equal(t, BackwardCompatible, BackwardCompatible.Combine(Compatible))
+	require.Equal(t, Breaking, BackwardCompatible.Combine(ForwardCompatible))
+	require.Equal(t, Breaking, Breaking.Combine(Compatible))
+}
@@ -0,0 +1,465 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// WriteMarkdown writes a reference for s to w as Markdown. The page has a
+// section for the root schema and for each of the definitions, with a table
+// describing the properties of each object: their type, whether they are
+// required, their default, enumerated values, format, bounds and
+// description. References to definitions link to their sections.
+//
+// Sections start with the root schema, followed by the definitions in the
+// order they are first referred to, following the order of properties, and
+// then any other definitions by name.
+func WriteMarkdown(w io.Writer, s *Schema) error {
+	p := newDocPage(s)
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "# %s\n", p.Title)
+	if p.Description != "" {
+		fmt.Fprintf(b, "\n%s\n", p.Description)
+	}
+	for _, section := range p.Sections {
+		fmt.Fprintf(b, "\n## %s\n", section.Name)
+		if section.Description != "" {
+			fmt.Fprintf(b, "\n%s\n", section.Description)
+		}
+		if len(section.Summary) > 0 {
+			b.WriteString("\n")
+			for _, item := range section.Summary {
+				fmt.Fprintf(b, "- **%s:** %s\n", item.Name, markdownCell(item.Value))
+			}
+		}
+		if len(section.Rows) > 0 {
+			b.WriteString("\n| Property | Type | Required | Default | Enum | Format | Bounds | Description |\n")
+			b.WriteString("| --- | --- | --- | --- | --- | --- | --- | --- |\n")
+			for _, row := range section.Rows {
+				required := "no"
+				if row.Required {
+					required = "yes"
+				}
+				fmt.Fprintf(b, "| %s | %s | %s | %s | %s | %s | %s | %s |\n",
+					markdownCell(docText{}.code(row.Name)), markdownCell(row.Type), required, markdownCell(row.Default), markdownCell(row.Enum),
+					markdownCell(row.Format), markdownCell(row.Bounds), markdownCell(row.Description))
+			}
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// WriteHTML writes the reference described for WriteMarkdown to w as a
+// self-contained HTML page.
+func WriteHTML(w io.Writer, s *Schema) error {
+	return docTemplate.Execute(w, newDocPage(s))
+}
+
+// A docPage is the content of a reference, independent of its format.
+type docPage struct {
+	Title       string
+	Description string
+	Sections    []docSection
+}
+
+type docSection struct {
+	Name        string
+	Anchor      string
+	Description string
+	// Summary describes schemas that aren't objects.
+	Summary []docItem
+	Rows    []docRow
+}
+
+type docItem struct {
+	Name  string
+	Value docText
+}
+
+type docRow struct {
+	Name        string
+	Type        docText
+	Required    bool
+	Default     docText
+	Enum        docText
+	Format      docText
+	Bounds      docText
+	Description docText
+}
+
+// docText is a sequence of spans of text, which are either plain, code or
+// links to sections.
+type docText []docSpan
+
+type docSpan struct {
+	Text   string
+	Code   bool
+	Anchor string
+}
+
+func (t docText) plain(s string) docText { return append(t, docSpan{Text: s}) }
+func (t docText) code(s string) docText  { return append(t, docSpan{Text: s, Code: true}) }
+func (t docText) join(o docText) docText { return append(t, o...) }
+
+// link appends a link to the section for the definition name.
+func (t docText) link(name string) docText {
+	return append(t, docSpan{Text: name, Code: true, Anchor: docAnchor(name)})
+}
+
+func newDocPage(s *Schema) *docPage {
+	root := s.Type
+	if root == nil {
+		root = &Type{}
+	}
+	p := &docPage{Title: root.Title, Description: root.Description}
+	rootName := strings.TrimPrefix(root.Ref, definitionsPrefix)
+	if _, ok := s.Definitions[rootName]; !ok || rootName == root.Ref {
+		rootName = ""
+	}
+	if p.Title == "" {
+		p.Title = rootName
+	}
+	if p.Title == "" {
+		p.Title = "Schema"
+	}
+
+	if rootName == "" {
+		p.Sections = append(p.Sections, newDocSection(p.Title, root))
+	}
+	for _, name := range definitionOrder(s) {
+		p.Sections = append(p.Sections, newDocSection(name, s.Definitions[name]))
+	}
+	return p
+}
+
+// definitionOrder returns the names of the definitions of s, in the order
+// they are first referred to from the root, followed by the others.
+func definitionOrder(s *Schema) []string {
+	var names []string
+	seen := map[string]bool{}
+	var visit Visitor
+	visit = func(_ string, _, t *Type) error {
+		name := strings.TrimPrefix(t.Ref, definitionsPrefix)
+		if def, ok := s.Definitions[name]; ok && name != t.Ref && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+			_ = Walk(def, visit)
+		}
+		return nil
+	}
+	if s.Type != nil {
+		_ = Walk(s.Type, visit)
+	}
+	for _, name := range sortedDefinitionNames(s.Definitions) {
+		if !seen[name] {
+			names = append(names, name)
+			_ = Walk(s.Definitions[name], visit)
+		}
+	}
+	return names
+}
+
+func newDocSection(name string, t *Type) docSection {
+	section := docSection{Name: name, Anchor: docAnchor(name), Description: t.Description}
+	if t.Properties == nil {
+		add := func(name string, value docText) {
+			if len(value) > 0 {
+				section.Summary = append(section.Summary, docItem{name, value})
+			}
+		}
+		add("Type", docType(t))
+		add("Default", docValue(t.Default))
+		add("Enum", docValues(t.Enum))
+		add("Format", docFormat(t))
+		add("Bounds", docBounds(t))
+		return section
+	}
+
+	required := stringSet(t.Required)
+	properties := propertyMap(t)
+	for _, name := range propertyNames(t) {
+		p := properties[name]
+		if p == nil {
+			continue
+		}
+		// Nullable properties are described by their non-null alternative.
+		d := p
+		if alternative := nonNullAlternative(p); alternative != nil {
+			d = alternative
+		}
+		description := p.Description
+		if description == "" {
+			description = d.Description
+		}
+		def := p.Default
+		if def == nil {
+			def = d.Default
+		}
+		section.Rows = append(section.Rows, docRow{
+			Name:        name,
+			Type:        docType(p),
+			Required:    required[name],
+			Default:     docValue(def),
+			Enum:        docValues(d.Enum),
+			Format:      docFormat(d),
+			Bounds:      docBounds(d),
+			Description: docText{}.plain(description),
+		})
+	}
+	return section
+}
+
+// nonNullAlternative returns the alternative of a nullable schema that
+// isn't null, if t is one.
+func nonNullAlternative(t *Type) *Type {
+	if len(t.AdditionalTypes) > 0 {
+		var types []string
+		for _, typ := range t.types() {
+			if typ != "null" {
+				types = append(types, typ)
+			}
+		}
+		if len(types) == 0 || len(types) > len(t.AdditionalTypes) {
+			return nil
+		}
+		c := *t
+		c.Type, c.AdditionalTypes = types[0], types[1:]
+		if len(c.AdditionalTypes) == 0 {
+			c.AdditionalTypes = nil
+		}
+		return &c
+	}
+	if t.Type != "" || len(t.OneOf)+len(t.AnyOf) != 2 {
+		return nil
+	}
+	alternatives := append(t.OneOf[:len(t.OneOf):len(t.OneOf)], t.AnyOf...)
+	for i, a := range alternatives {
+		if a != nil && a.Type == "null" {
+			return alternatives[1-i]
+		}
+	}
+	return nil
+}
+
+// docType describes the type of t, linking to the definitions it refers to.
+func docType(t *Type) docText {
+	if t == nil {
+		return docText{}.plain("any")
+	}
+	if name := strings.TrimPrefix(t.Ref, definitionsPrefix); name != t.Ref {
+		return docText{}.link(name)
+	}
+	if t.Ref != "" {
+		return docText{}.code(t.Ref)
+	}
+	var alternatives []*Type
+	switch {
+	case len(t.OneOf) > 0:
+		alternatives = t.OneOf
+	case len(t.AnyOf) > 0:
+		alternatives = t.AnyOf
+	}
+	if len(t.AdditionalTypes) > 0 {
+		alternatives = typeAlternatives(t)
+	}
+	if (t.Type == "" || len(t.AdditionalTypes) > 0) && len(alternatives) > 0 {
+		var text docText
+		for i, a := range alternatives {
+			if i > 0 {
+				text = text.plain(" or ")
+			}
+			text = text.join(docType(a))
+		}
+		return text
+	}
+
+	switch {
+	case t.Type == "array" || (t.Type == "" && t.Items != nil):
+		if t.Items == nil {
+			return docText{}.plain("array")
+		}
+		return docText{}.plain("array of ").join(docType(t.Items))
+	case (t.Type == "object" || t.Type == "") && t.Properties == nil && len(t.PatternProperties) == 1:
+		for _, v := range t.PatternProperties {
+			return docText{}.plain("map of ").join(docType(v))
+		}
+	case t.Type == "":
+		return docText{}.plain("any")
+	}
+	return docText{}.plain(t.Type)
+}
+
+func docValue(v interface{}) docText {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return docText{}.plain(fmt.Sprint(v))
+	}
+	return docText{}.code(string(b))
+}
+
+func docValues(vs []interface{}) docText {
+	var text docText
+	for i, v := range vs {
+		if i > 0 {
+			text = text.plain(", ")
+		}
+		text = text.join(docValue(v))
+	}
+	return text
+}
+
+func docFormat(t *Type) docText {
+	var text docText
+	if t.Format != "" {
+		text = text.plain(t.Format)
+	}
+	if t.Media != nil && t.Media.BinaryEncoding != "" {
+		text = docJoin(text, docText{}.plain(t.Media.BinaryEncoding))
+	}
+	if t.Pattern != "" {
+		text = docJoin(text, docText{}.plain("pattern ").code(t.Pattern))
+	}
+	return text
+}
+
+func docBounds(t *Type) docText {
+	var text docText
+	bound := func(keyword string, v int, exclusive bool) {
+		if v == 0 && !exclusive {
+			return
+		}
+		if exclusive {
+			keyword = "exclusive " + keyword
+		}
+		text = docJoin(text, docText{}.plain(fmt.Sprintf("%s %d", keyword, v)))
+	}
+	bound("minimum", t.Minimum, t.ExclusiveMinimum)
+	bound("maximum", t.Maximum, t.ExclusiveMaximum)
+	bound("multipleOf", t.MultipleOf, false)
+	bound("minLength", t.MinLength, false)
+	bound("maxLength", t.MaxLength, false)
+	bound("minItems", t.MinItems, false)
+	bound("maxItems", t.MaxItems, false)
+	bound("minProperties", t.MinProperties, false)
+	bound("maxProperties", t.MaxProperties, false)
+	if t.UniqueItems {
+		text = docJoin(text, docText{}.plain("unique items"))
+	}
+	return text
+}
+
+// docJoin joins two texts with a comma.
+func docJoin(a, b docText) docText {
+	if len(a) == 0 {
+		return b
+	}
+	return a.plain(", ").join(b)
+}
+
+// docAnchor returns the anchor of the section for a definition, the way
+// GitHub derives anchors from headings.
+func docAnchor(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			return unicode.ToLower(r)
+		case r == ' ':
+			return '-'
+		}
+		return -1
+	}, name)
+}
+
+// markdownCell renders text for use within a table cell.
+func markdownCell(t docText) string {
+	b := &strings.Builder{}
+	for _, span := range t {
+		text := span.Text
+		if span.Code {
+			text = markdownCode(text)
+		} else {
+			text = markdownEscape(text)
+		}
+		if span.Anchor != "" {
+			text = fmt.Sprintf("[%s](#%s)", text, span.Anchor)
+		}
+		b.WriteString(text)
+	}
+	return strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>").Replace(b.String())
+}
+
+// markdownCode renders s as inline code, using enough backticks to contain
+// those in s.
+func markdownCode(s string) string {
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+func markdownEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", "&lt;").Replace(s)
+}
+
+var docTemplate = template.Must(template.New("doc").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.5; max-width: 72em; margin: 2em auto; padding: 0 1em; color: #24292f; }
+table { border-collapse: collapse; width: 100%; margin: 1em 0; }
+th, td { border: 1px solid #d0d7de; padding: 0.4em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+code { background: #f6f8fa; padding: 0.1em 0.3em; border-radius: 4px; font-size: 90%; }
+a { color: #0969da; text-decoration: none; }
+a:hover { text-decoration: underline; }
+section { margin-top: 2em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- with .Description}}
+<p>{{.}}</p>
+{{- end}}
+{{- range .Sections}}
+<section id="{{.Anchor}}">
+<h2>{{.Name}}</h2>
+{{- with .Description}}
+<p>{{.}}</p>
+{{- end}}
+{{- with .Summary}}
+<dl>
+{{- range .}}
+<dt>{{.Name}}</dt><dd>{{template "text" .Value}}</dd>
+{{- end}}
+</dl>
+{{- end}}
+{{- with .Rows}}
+<table>
+<thead><tr><th>Property</th><th>Type</th><th>Required</th><th>Default</th><th>Enum</th><th>Format</th><th>Bounds</th><th>Description</th></tr></thead>
+<tbody>
+{{- range .}}
+<tr><td><code>{{.Name}}</code></td><td>{{template "text" .Type}}</td><td>{{if .Required}}yes{{else}}no{{end}}</td><td>{{template "text" .Default}}</td><td>{{template "text" .Enum}}</td><td>{{template "text" .Format}}</td><td>{{template "text" .Bounds}}</td><td>{{template "text" .Description}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+{{define "text"}}{{range .}}{{if .Anchor}}<a href="#{{.Anchor}}">{{end}}{{if .Code}}<code>{{.Text}}</code>{{else}}{{.Text}}{{end}}{{if .Anchor}}</a>{{end}}{{end}}{{end}}`))
@@ -0,0 +1,100 @@
+package jsonschema
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type DocsListener struct {
+	Address string `json:"address" jsonschema:"description=Address to listen on,format=hostname"`
+	Port    int    `json:"port" jsonschema:"minimum=1,maximum=65535,default=8080"`
+}
+
+type DocsLevel string
+
+type DocsService struct {
+	Name      string            `json:"name" jsonschema:"description=Name of the service | used in logs,minLength=1,maxLength=63,pattern=^[a-z]+$"`
+	Listeners []DocsListener    `json:"listeners" jsonschema:"minItems=1"`
+	Level     DocsLevel         `json:"level,omitempty"`
+	Timeout   *float64          `json:"timeout,omitempty" jsonschema:"nullable,exclusiveMinimum=true"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Tags      []string          `json:"tags,omitempty" jsonschema:"uniqueItems=true"`
+}
+
+func (DocsLevel) JSONSchemaType() *Type {
+	return &Type{Type: "string", Description: "Verbosity of logs.", Enum: []interface{}{"debug", "info"}, Default: "info"}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	s := Reflect(&DocsService{})
+	s.Description = "Configuration of a service."
+	b := &bytes.Buffer{}
+	require.NoError(t, WriteMarkdown(b, s))
+	expected, err := ioutil.ReadFile("fixtures/docs.md")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), b.String())
+}
+
+func TestWriteHTML(t *testing.T) {
+	s := Reflect(&DocsService{})
+	s.Description = "Configuration of a service."
+	b := &bytes.Buffer{}
+	require.NoError(t, WriteHTML(b, s))
+	expected, err := ioutil.ReadFile("fixtures/docs.html")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), b.String())
+}
+
+func TestWriteMarkdownInlineRoot(t *testing.T) {
+	r := &Reflector{ExpandedStruct: true}
+	s := r.Reflect(&DocsService{})
+	s.Title = "Service"
+	b := &bytes.Buffer{}
+	require.NoError(t, WriteMarkdown(b, s))
+	out := b.String()
+
+	// The root comes first and its definitions follow in property order,
+	// whatever their names.
+	require.True(t, strings.HasPrefix(out, "# Service\n\n## Service\n"), out)
+	listener := strings.Index(out, "## DocsListener")
+	level := strings.Index(out, "## DocsLevel")
+	require.True(t, listener > 0 && level > listener, out)
+	require.Contains(t, out, "| `listeners` | array of [`DocsListener`](#docslistener) | yes |")
+}
+
+func TestDefinitionOrder(t *testing.T) {
+	s := &Schema{
+		Type: &Type{Ref: "#/definitions/Root"},
+		Definitions: Definitions{
+			"Root":   {Type: "object", Properties: props("z", &Type{Ref: "#/definitions/Zed"}, "a", &Type{Ref: "#/definitions/Alpha"})},
+			"Alpha":  {Type: "object", Properties: props("self", &Type{Ref: "#/definitions/Alpha"}, "zed", &Type{Ref: "#/definitions/Zed"})},
+			"Zed":    {Type: "string"},
+			"Unused": {Type: "string"},
+			"Apart":  {Type: "object", Properties: props("b", &Type{Ref: "#/definitions/Beta"})},
+			"Beta":   {Type: "integer"},
+		},
+	}
+	require.Equal(t, []string{"Root", "Zed", "Alpha", "Apart", "Beta", "Unused"}, definitionOrder(s))
+}
+
+func TestWriteMarkdownPropertyNames(t *testing.T) {
+	s := &Schema{Type: &Type{
+		Type:       "object",
+		Properties: props("a|b", &Type{Type: "string"}, "c`d", &Type{Type: "integer"}),
+	}}
+	b := &bytes.Buffer{}
+	require.NoError(t, WriteMarkdown(b, s))
+	require.Contains(t, b.String(), "| `a\\|b` | string | no |")
+	require.Contains(t, b.String(), "| ``c`d`` | integer | no |")
+}
+
+func TestMarkdownCell(t *testing.T) {
+	require.Equal(t, "a \\| b<br>c", markdownCell(docText{}.plain("a | b\nc")))
+	require.Equal(t, "``a`b``", markdownCell(docText{}.code("a`b")))
+	require.Equal(t, "[`Name`](#name)", markdownCell(docText{}.link("Name")))
+	require.Equal(t, "\\*not\\* \\_emphasis\\_", markdownCell(docText{}.plain("*not* _emphasis_")))
+}
@@ -0,0 +1,559 @@
+package jsonschema
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp/syntax"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/iancoleman/orderedmap"
+)
+
+// ExampleMode controls how much of a schema an example covers.
+type ExampleMode int
+
+const (
+	// MinimalExample only includes the required properties of objects and
+	// the minimum number of items in arrays.
+	MinimalExample ExampleMode = iota
+	// MaximalExample includes every property of objects and at least one
+	// item in arrays.
+	MaximalExample
+)
+
+// An ExampleGenerator generates example instances of schemas, for use in
+// documentation and tests.
+//
+// The first of a schema's "examples", its "default" or the first value of
+// its "enum" is used when there is one. Otherwise a value is made up that
+// honours the schema's type, format, pattern and bounds, following "$ref"s
+// into the definitions of the schema being generated.
+type ExampleGenerator struct {
+	Mode ExampleMode
+}
+
+// GenerateExample returns a minimal example instance of s. See
+// ExampleGenerator for details.
+func GenerateExample(s *Schema) interface{} {
+	return (&ExampleGenerator{}).Generate(s)
+}
+
+// Generate returns an example instance of s. Objects are returned as
+// *orderedmap.OrderedMap so that their properties keep the order of the
+// schema when marshalled, arrays as []interface{}, and integers as int64.
+func (g *ExampleGenerator) Generate(s *Schema) interface{} {
+	ig := &instanceGenerator{
+		definitions: s.Definitions,
+		mode:        g.Mode,
+		expanding:   map[string]bool{},
+	}
+	return ig.generate(s.Type)
+}
+
+// instanceGenerator generates instances of the schemas within a document.
+// Choices are made by intn, which always picks the first option unless rand
+// is set.
+type instanceGenerator struct {
+	definitions Definitions
+	mode        ExampleMode
+	rand        *rand.Rand
+	// expanding holds the definitions being generated, to stop recursive
+	// definitions from being expanded forever.
+	expanding map[string]bool
+}
+
+// intn returns a number in [0, n), which is always 0 without rand.
+func (g *instanceGenerator) intn(n int) int {
+	if g.rand == nil || n <= 1 {
+		return 0
+	}
+	return g.rand.Intn(n)
+}
+
+// definition returns the name of the definition t refers to, if any.
+func (g *instanceGenerator) definition(t *Type) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	name := strings.TrimPrefix(t.Ref, definitionsPrefix)
+	_, ok := g.definitions[name]
+	return name, ok && name != t.Ref
+}
+
+// recursive reports whether t refers to a definition already being
+// generated.
+func (g *instanceGenerator) recursive(t *Type) bool {
+	name, ok := g.definition(t)
+	return ok && g.expanding[name]
+}
+
+func (g *instanceGenerator) generate(t *Type) interface{} {
+	if t == nil {
+		return nil
+	}
+	if name, ok := g.definition(t); ok {
+		if g.expanding[name] {
+			return nil
+		}
+		g.expanding[name] = true
+		defer delete(g.expanding, name)
+		return g.generate(g.definitions[name])
+	}
+
+	// Random instances only use examples and defaults some of the time.
+	switch {
+	case len(t.Examples) > 0 && g.intn(4) == 0:
+		return t.Examples[g.intn(len(t.Examples))]
+	case t.Default != nil && g.intn(4) == 0:
+		return t.Default
+	case len(t.Enum) > 0:
+		return t.Enum[g.intn(len(t.Enum))]
+	}
+
+	t = g.combine(t)
+	switch t.Type {
+	case "object":
+		return g.generateObject(t)
+	case "array":
+		return g.generateArray(t)
+	case "string":
+		return g.generateString(t)
+	case "integer":
+		return int64(g.generateNumber(t, true))
+	case "number":
+		return g.generateNumber(t, false)
+	case "boolean":
+		return g.intn(2) == 1
+	case "null":
+		return nil
+	}
+	switch {
+	case t.Properties != nil || t.PatternProperties != nil:
+		return g.generateObject(t)
+	case t.Items != nil:
+		return g.generateArray(t)
+	}
+	return nil
+}
+
+// combine merges t with the schemas of its allOf and one of its anyOf or
+// oneOf alternatives, so that a single value can satisfy all of them.
+// Alternatives that only allow null are avoided, unless choosing at random.
+func (g *instanceGenerator) combine(t *Type) *Type {
+	if len(t.AllOf) == 0 && len(t.AnyOf) == 0 && len(t.OneOf) == 0 {
+		return t
+	}
+	// Properties are copied, as merging adds to them.
+	c := *t
+	c.AllOf, c.AnyOf, c.OneOf = nil, nil, nil
+	c.Properties, c.PatternProperties = nil, nil
+	mergeType(&c, t)
+	for _, s := range t.AllOf {
+		mergeType(&c, g.combine(g.resolve(s)))
+	}
+	for _, alternatives := range [][]*Type{t.AnyOf, t.OneOf} {
+		if s := g.chooseAlternative(alternatives); s != nil {
+			mergeType(&c, g.combine(s))
+		}
+	}
+	return &c
+}
+
+func (g *instanceGenerator) chooseAlternative(alternatives []*Type) *Type {
+	var candidates []*Type
+	for _, s := range alternatives {
+		if s = g.resolve(s); s != nil && (s.Type != "null" || g.rand != nil) {
+			candidates = append(candidates, s)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[g.intn(len(candidates))]
+}
+
+// resolve follows t to the definition it refers to, unless that definition
+// is already being generated.
+func (g *instanceGenerator) resolve(t *Type) *Type {
+	for i := 0; i < 32; i++ {
+		name, ok := g.definition(t)
+		if !ok || g.expanding[name] {
+			break
+		}
+		t = g.definitions[name]
+	}
+	return t
+}
+
+// mergeType adds the keywords of s that aren't set in t to t, and the
+// required properties of s to those of t.
+func mergeType(t, s *Type) {
+	if t.Type == "" {
+		t.Type = s.Type
+	}
+	if t.Format == "" {
+		t.Format = s.Format
+	}
+	if t.Pattern == "" {
+		t.Pattern = s.Pattern
+	}
+	if len(t.Enum) == 0 {
+		t.Enum = s.Enum
+	}
+	if t.Items == nil {
+		t.Items = s.Items
+	}
+	if t.AdditionalProperties == nil {
+		t.AdditionalProperties = s.AdditionalProperties
+	}
+	if t.Media == nil {
+		t.Media = s.Media
+	}
+	for _, bound := range []struct{ t, s *int }{
+		{&t.Minimum, &s.Minimum}, {&t.Maximum, &s.Maximum}, {&t.MultipleOf, &s.MultipleOf},
+		{&t.MinLength, &s.MinLength}, {&t.MaxLength, &s.MaxLength},
+		{&t.MinItems, &s.MinItems}, {&t.MaxItems, &s.MaxItems},
+		{&t.MinProperties, &s.MinProperties}, {&t.MaxProperties, &s.MaxProperties},
+	} {
+		if *bound.t == 0 {
+			*bound.t = *bound.s
+		}
+	}
+	t.ExclusiveMinimum = t.ExclusiveMinimum || s.ExclusiveMinimum
+	t.ExclusiveMaximum = t.ExclusiveMaximum || s.ExclusiveMaximum
+	t.UniqueItems = t.UniqueItems || s.UniqueItems
+
+	for _, name := range propertyNames(s) {
+		if t.Properties == nil {
+			t.Properties = orderedmap.New()
+		} else if _, ok := t.Properties.Get(name); ok {
+			continue
+		}
+		p, _ := s.Properties.Get(name)
+		t.Properties.Set(name, p)
+	}
+	required := stringSet(t.Required)
+	for _, name := range s.Required {
+		if !required[name] {
+			t.Required = append(t.Required[:len(t.Required):len(t.Required)], name)
+		}
+	}
+	for pattern, p := range s.PatternProperties {
+		if _, ok := t.PatternProperties[pattern]; !ok {
+			if t.PatternProperties == nil {
+				t.PatternProperties = map[string]*Type{}
+			}
+			t.PatternProperties[pattern] = p
+		}
+	}
+}
+
+func (g *instanceGenerator) generateObject(t *Type) interface{} {
+	properties := propertyMap(t)
+	required := stringSet(t.Required)
+	o := orderedmap.New()
+	for _, name := range t.Required {
+		if _, ok := properties[name]; !ok {
+			o.Set(name, g.generate(additionalPropertiesSchema(t)))
+		}
+	}
+	for _, name := range propertyNames(t) {
+		if !required[name] && !g.includeOptional(t, o, properties[name]) {
+			continue
+		}
+		o.Set(name, g.generate(properties[name]))
+	}
+
+	// Maps are described by patternProperties alone, with keys made up from
+	// the patterns.
+	patterns := sortedDefinitionNames(t.PatternProperties)
+	n := t.MinProperties - len(o.Keys())
+	if g.mode == MaximalExample && n < len(patterns) {
+		n = len(patterns)
+	}
+	if g.rand != nil {
+		n += g.intn(4)
+	}
+	for i := 0; i < n && len(patterns) > 0; i++ {
+		pattern := patterns[i%len(patterns)]
+		key := g.generatePattern(pattern)
+		// Random keys differ well enough on their own.
+		if key == "" || (i >= len(patterns) && g.rand == nil) {
+			key += fmt.Sprintf("key%d", i)
+		}
+		o.Set(key, g.generate(t.PatternProperties[pattern]))
+	}
+	return o
+}
+
+// includeOptional reports whether an optional property with the schema p
+// should be added to o, an instance of t.
+func (g *instanceGenerator) includeOptional(t *Type, o *orderedmap.OrderedMap, p *Type) bool {
+	n := len(o.Keys())
+	switch {
+	case t.MaxProperties > 0 && n >= t.MaxProperties:
+		return false
+	case n < t.MinProperties:
+		return true
+	case g.recursive(p):
+		return false
+	case g.rand != nil:
+		return g.intn(2) == 1
+	default:
+		return g.mode == MaximalExample
+	}
+}
+
+func (g *instanceGenerator) generateArray(t *Type) interface{} {
+	n := t.MinItems
+	if g.mode == MaximalExample && n == 0 && !g.recursive(t.Items) {
+		n = 1
+	}
+	if g.rand != nil && !g.recursive(t.Items) {
+		n += g.intn(4)
+	}
+	if t.MaxItems > 0 && n > t.MaxItems {
+		n = t.MaxItems
+	}
+
+	// Unique items are generated again until they differ, which may leave
+	// fewer of them than wanted when there aren't enough distinct values.
+	items := make([]interface{}, 0, n)
+	seen := map[string]bool{}
+	for attempts := 0; len(items) < n && attempts < 4*n+4; attempts++ {
+		item := g.generate(t.Items)
+		if key := diffValue(item); t.UniqueItems {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// exampleFormats holds a sample value for each string format.
+var exampleFormats = map[string]string{
+	"date-time":     "2006-01-02T15:04:05Z",
+	"date":          "2006-01-02",
+	"time":          "15:04:05Z",
+	"email":         "user@example.com",
+	"idn-email":     "user@example.com",
+	"hostname":      "example.com",
+	"idn-hostname":  "example.com",
+	"ipv4":          "192.0.2.1",
+	"ipv6":          "2001:db8::1",
+	"uri":           "https://example.com/",
+	"iri":           "https://example.com/",
+	"uri-reference": "/example",
+	"iri-reference": "/example",
+	"uri-template":  "https://example.com/{id}",
+	"json-pointer":  "/example",
+	"regex":         ".*",
+	"uuid":          "123e4567-e89b-12d3-a456-426614174000",
+}
+
+func (g *instanceGenerator) generateString(t *Type) interface{} {
+	if s, ok := g.generateFormat(t.Format); ok {
+		return s
+	}
+	if t.Pattern != "" {
+		return g.generatePattern(t.Pattern)
+	}
+
+	s := "string"
+	if g.rand != nil {
+		s = g.randomString(t.MinLength + g.intn(9))
+	}
+	if t.Media != nil && t.Media.BinaryEncoding == "base64" {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	for utf8.RuneCountInString(s) < t.MinLength {
+		s += "x"
+	}
+	if t.MaxLength > 0 && utf8.RuneCountInString(s) > t.MaxLength {
+		s = string([]rune(s)[:t.MaxLength])
+	}
+	return s
+}
+
+// generateFormat returns a string in the given format, if it is known.
+func (g *instanceGenerator) generateFormat(format string) (string, bool) {
+	if g.rand == nil {
+		s, ok := exampleFormats[format]
+		return s, ok
+	}
+	date := time.Unix(int64(g.rand.Int31()), 0).UTC()
+	switch format {
+	case "date-time":
+		return date.Format(time.RFC3339), true
+	case "date":
+		return date.Format("2006-01-02"), true
+	case "time":
+		return date.Format("15:04:05Z07:00"), true
+	case "email", "idn-email":
+		return g.randomString(1+g.intn(8)) + "@example.com", true
+	case "hostname", "idn-hostname":
+		return strings.ToLower(g.randomString(1+g.intn(8))) + ".example.com", true
+	case "ipv4":
+		return fmt.Sprintf("%d.%d.%d.%d", g.intn(256), g.intn(256), g.intn(256), g.intn(256)), true
+	case "uri", "iri":
+		return "https://example.com/" + g.randomString(g.intn(9)), true
+	}
+	s, ok := exampleFormats[format]
+	return s, ok
+}
+
+func (g *instanceGenerator) randomString(n int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = letters[g.intn(len(letters))]
+	}
+	return string(b)
+}
+
+// generatePattern returns a string matching the regular expression pattern,
+// or an empty string if it cannot be parsed.
+func (g *instanceGenerator) generatePattern(pattern string) string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	b := &strings.Builder{}
+	g.writePattern(b, re.Simplify())
+	return b.String()
+}
+
+func (g *instanceGenerator) writePattern(b *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		b.WriteRune(g.chooseRune(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteByte(byte('a' + g.intn(26)))
+	case syntax.OpCapture:
+		g.writePattern(b, re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			g.writePattern(b, sub)
+		}
+	case syntax.OpAlternate:
+		g.writePattern(b, re.Sub[g.intn(len(re.Sub))])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		// Repetitions are kept to their minimum unless choosing at random,
+		// with unbounded ones repeated at most three more times.
+		min, max := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, -1
+		case syntax.OpPlus:
+			min, max = 1, -1
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		if max < 0 {
+			max = min + 3
+		}
+		for n := min + g.intn(max-min+1); n > 0; n-- {
+			g.writePattern(b, re.Sub[0])
+		}
+	}
+}
+
+// chooseRune picks a rune from a character class, given as pairs of range
+// bounds, preferring readable ones unless choosing at random.
+func (g *instanceGenerator) chooseRune(ranges []rune) rune {
+	if len(ranges) == 0 {
+		return 'a'
+	}
+	if g.rand != nil {
+		i := 2 * g.intn(len(ranges)/2)
+		return ranges[i] + rune(g.intn(int(ranges[i+1]-ranges[i])+1))
+	}
+	for _, r := range "a0A_-. " {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if ranges[i] <= r && r <= ranges[i+1] {
+				return r
+			}
+		}
+	}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if ranges[i+1] > ' ' {
+			if ranges[i] > ' ' {
+				return ranges[i]
+			}
+			return ' ' + 1
+		}
+	}
+	return ranges[0]
+}
+
+// numberBounds returns the inclusive range of values allowed by t. Zero
+// bounds are omitted from schemas, so are treated as no bound unless they
+// are exclusive.
+func numberBounds(t *Type, integer bool) (float64, float64) {
+	step := 0.5
+	if integer {
+		step = 1
+	}
+	lo, hi := math.Inf(-1), math.Inf(1)
+	if t.Minimum != 0 || t.ExclusiveMinimum {
+		lo = float64(t.Minimum)
+		if t.ExclusiveMinimum {
+			lo += step
+		}
+	}
+	if t.Maximum != 0 || t.ExclusiveMaximum {
+		hi = float64(t.Maximum)
+		if t.ExclusiveMaximum {
+			hi -= step
+		}
+	}
+	return lo, hi
+}
+
+// generateNumber returns the number closest to zero allowed by t, or a
+// random one within its bounds. Random integers without bounds are kept
+// within [0, 127] so that they fit any Go integer type, and other numbers
+// within [-1000, 1000].
+func (g *instanceGenerator) generateNumber(t *Type, integer bool) float64 {
+	lo, hi := numberBounds(t, integer)
+	v := math.Max(lo, math.Min(0, hi))
+	if g.rand != nil {
+		from, to := g.randomRange(lo, hi, integer)
+		v = from + g.rand.Float64()*(to-from)
+		if integer {
+			v = math.Floor(v + 0.5)
+		}
+	}
+	if m := float64(t.MultipleOf); m > 0 {
+		v = math.Ceil(v/m) * m
+		if v > hi {
+			v -= m
+		}
+	}
+	return v
+}
+
+func (g *instanceGenerator) randomRange(lo, hi float64, integer bool) (float64, float64) {
+	from, span := -1000.0, 2000.0
+	if integer {
+		from, span = 0, 127
+	}
+	switch {
+	case math.IsInf(lo, -1) && math.IsInf(hi, 1):
+		return from, from + span
+	case math.IsInf(lo, -1):
+		return hi - span, hi
+	case math.IsInf(hi, 1):
+		return lo, lo + span
+	}
+	return lo, hi
+}
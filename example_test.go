@@ -0,0 +1,129 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/iancoleman/orderedmap"
+	"github.com/stretchr/testify/require"
+)
+
+type ExampleAddress struct {
+	Street string `json:"street" jsonschema:"example=1 Main Street"`
+	Zip    string `json:"zip" jsonschema:"pattern=^[0-9]{5}(-[0-9]{4})?$"`
+	Unit   string `json:"unit,omitempty" jsonschema:"minLength=3"`
+}
+
+type ExampleOrder struct {
+	ID        int               `json:"id" jsonschema:"minimum=100,multipleOf=7"`
+	Email     string            `json:"email" jsonschema:"format=email"`
+	Status    string            `json:"status" jsonschema:"enum=pending,enum=shipped"`
+	Currency  string            `json:"currency,omitempty" jsonschema:"default=EUR"`
+	Placed    time.Time         `json:"placed"`
+	Homepage  url.URL           `json:"homepage,omitempty"`
+	Server    net.IP            `json:"server,omitempty"`
+	Discount  float64           `json:"discount,omitempty" jsonschema:"maximum=-5,exclusiveMaximum=true"`
+	Code      string            `json:"code,omitempty" jsonschema:"maxLength=3"`
+	Lines     []ExampleAddress  `json:"lines" jsonschema:"minItems=2"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Quantites map[int]int       `json:"quantities,omitempty"`
+	Gift      *bool             `json:"gift,omitempty" jsonschema:"nullable"`
+	Payload   []byte            `json:"payload,omitempty"`
+	Extra     interface{}       `json:"extra,omitempty"`
+	Tree      *TreeNode         `json:"tree,omitempty"`
+}
+
+// props builds properties from alternating names and schemas.
+func props(namesAndTypes ...interface{}) *orderedmap.OrderedMap {
+	properties := orderedmap.New()
+	for i := 0; i < len(namesAndTypes); i += 2 {
+		properties.Set(namesAndTypes[i].(string), namesAndTypes[i+1])
+	}
+	return properties
+}
+
+func exampleJSON(t *testing.T, g *ExampleGenerator, s *Schema) string {
+	t.Helper()
+	b, err := json.Marshal(g.Generate(s))
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestGenerateExample(t *testing.T) {
+	s := Reflect(&ExampleOrder{})
+
+	require.JSONEq(t, `{
+		"id": 105,
+		"email": "user@example.com",
+		"status": "pending",
+		"placed": "2006-01-02T15:04:05Z",
+		"lines": [
+			{"street": "1 Main Street", "zip": "00000"},
+			{"street": "1 Main Street", "zip": "00000"}
+		]
+	}`, exampleJSON(t, &ExampleGenerator{}, s))
+
+	require.JSONEq(t, `{
+		"id": 105,
+		"email": "user@example.com",
+		"status": "pending",
+		"currency": "EUR",
+		"placed": "2006-01-02T15:04:05Z",
+		"homepage": "https://example.com/",
+		"server": "192.0.2.1",
+		"discount": -5.5,
+		"code": "str",
+		"lines": [
+			{"street": "1 Main Street", "zip": "00000", "unit": "string"},
+			{"street": "1 Main Street", "zip": "00000", "unit": "string"}
+		],
+		"labels": {"key0": "string"},
+		"quantities": {"0": 0},
+		"gift": false,
+		"payload": "c3RyaW5n",
+		"extra": null,
+		"tree": {"name": "string", "children": []}
+	}`, exampleJSON(t, &ExampleGenerator{Mode: MaximalExample}, s))
+}
+
+func TestGenerateExamplePropertyOrder(t *testing.T) {
+	b, err := json.Marshal(GenerateExample(Reflect(&ExampleAddress{})))
+	require.NoError(t, err)
+	require.Equal(t, `{"street":"1 Main Street","zip":"00000"}`, string(b))
+}
+
+func TestGenerateExampleCombinations(t *testing.T) {
+	s := &Schema{
+		Type: &Type{
+			AllOf: []*Type{
+				{Ref: "#/definitions/Named"},
+				{Properties: props("age", &Type{Type: "integer", Minimum: 18}), Required: []string{"age"}},
+			},
+			OneOf: []*Type{
+				{Type: "null"},
+				{Required: []string{"nickname"}},
+			},
+		},
+		Definitions: Definitions{
+			"Named": {
+				Type:       "object",
+				Properties: props("name", &Type{Type: "string", MinLength: 8}, "nickname", &Type{Type: "string", Pattern: `^[a-z]+\d*$`}),
+				Required:   []string{"name"},
+			},
+		},
+	}
+	require.Equal(t, `{"name":"stringxx","nickname":"a","age":18}`, exampleJSON(t, &ExampleGenerator{}, s))
+
+	// The schemas combined must be left untouched.
+	require.Equal(t, []string{"name"}, s.Definitions["Named"].Required)
+	require.Equal(t, []string{"name", "nickname"}, s.Definitions["Named"].Properties.Keys())
+}
+
+func TestGenerateExampleRecursive(t *testing.T) {
+	s := Reflect(&TreeNode{})
+	require.Equal(t, `{"name":"string","children":[]}`, exampleJSON(t, &ExampleGenerator{Mode: MaximalExample}, s))
+	require.Equal(t, `{"name":"string"}`, exampleJSON(t, &ExampleGenerator{}, s))
+}
@@ -0,0 +1,8 @@
+package animals
+
+// Pet is a wild animal that has been adopted, sharing its name with
+// nested.Pet to test definition name collisions.
+type Pet struct {
+	// Species of the animal.
+	Species string `json:"species"`
+}
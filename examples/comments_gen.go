@@ -0,0 +1,18 @@
+// Code generated by jsonschema-comments. DO NOT EDIT.
+
+package examples
+
+// CommentMap holds the Go comments extracted from github.com/alecthomas/jsonschema/examples,
+// suitable for use as jsonschema.Reflector.CommentMap.
+var CommentMap = map[string]string{
+	"github.com/alecthomas/jsonschema/examples.User":                "User is used as a base to provide tests for comments.",
+	"github.com/alecthomas/jsonschema/examples.User.ID":             "Unique sequential identifier.",
+	"github.com/alecthomas/jsonschema/examples.User.Name":           "This comment will be ignored",
+	"github.com/alecthomas/jsonschema/examples.User.Pets":           "An array of pets the user cares for.",
+	"github.com/alecthomas/jsonschema/examples.User.Plants":         "Set of plants that the user likes",
+	"github.com/alecthomas/jsonschema/examples/animals.Pet":         "Pet is a wild animal that has been adopted, sharing its name with nested.Pet to test definition name collisions.",
+	"github.com/alecthomas/jsonschema/examples/animals.Pet.Species": "Species of the animal.",
+	"github.com/alecthomas/jsonschema/examples/nested.Pet":          "Pet defines the user's fury friend.",
+	"github.com/alecthomas/jsonschema/examples/nested.Pet.Name":     "Name of the animal.",
+	"github.com/alecthomas/jsonschema/examples/nested.Plant":        "Plant represents the plants the user might have and serves as a test of structs inside a `type` set.",
+}
@@ -1,5 +1,7 @@
 package examples
 
+//go:generate go run ../cmd/jsonschema-comments -base github.com/alecthomas/jsonschema/examples -o comments_gen.go
+
 import (
 	"github.com/alecthomas/jsonschema/examples/nested"
 )
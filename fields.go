@@ -0,0 +1,257 @@
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+)
+
+// A fieldInfo describes how the decoders described by the TagKeys of a
+// Reflector read a field.
+type fieldInfo struct {
+	// name is the name of the property of the field, or "" if it has none.
+	name string
+	// embed is set if the fields of the field are read as fields of the
+	// struct it belongs to.
+	embed bool
+	// remain is set if the field is a catch-all.
+	remain bool
+	// tagged is set if name is given by a tag.
+	tagged bool
+	// quoted is set if the value of the field is encoded as a JSON string,
+	// as encoding/json does for fields with the string option.
+	quoted   bool
+	required bool
+	nullable bool
+}
+
+// A structField is a field that is a property of the schema of a struct, or
+// a catch-all, possibly promoted from a struct embedded within it.
+type structField struct {
+	reflect.StructField
+	fieldInfo
+	// parent is the struct type the field is declared in.
+	parent reflect.Type
+	// index is the index sequence of the field within the struct. Fields
+	// added by AdditionalFields have indexes beyond those of the fields of
+	// their parent, and can't be accessed by them.
+	index []int
+	extra bool
+}
+
+// structFields returns the properties and catch-alls of the struct type t in
+// the order they're declared in, with the fields of embedded structs in place
+// of the structs. Fields are resolved as encoding/json resolves them: fields
+// of embedded structs are hidden by fields of the same name at a shallower
+// depth, and of the fields of a name at the shallowest depth one that is
+// tagged hides those that aren't. Fields of a name that none hides all hide
+// each other, so none of them are properties.
+func (r *Reflector) structFields(t reflect.Type) []structField {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	var (
+		fields    []structField
+		current   []embedded
+		next      = []embedded{{typ: t}}
+		count     map[reflect.Type]int
+		nextCount = map[reflect.Type]int{t: 1}
+		visited   = map[reflect.Type]bool{}
+	)
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+			for i, sf := range r.declaredFields(e.typ) {
+				f := structField{
+					StructField: sf,
+					fieldInfo:   r.reflectFieldName(sf),
+					parent:      e.typ,
+					index:       append(e.index[:len(e.index):len(e.index)], i),
+					extra:       i >= e.typ.NumField(),
+				}
+				switch {
+				case f.remain:
+					fields = append(fields, f)
+				case f.embed:
+					ft := sf.Type
+					if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if ft.Kind() != reflect.Struct {
+						continue
+					}
+					if nextCount[ft]++; nextCount[ft] == 1 {
+						next = append(next, embedded{typ: ft, index: f.index})
+					}
+				case f.name != "":
+					fields = append(fields, f)
+					if count[e.typ] > 1 {
+						// The struct is embedded more than once at this
+						// depth, so its fields hide each other.
+						fields = append(fields, f)
+					}
+				}
+			}
+		}
+	}
+
+	// Fields are found by increasing depth, so the first of a name is at
+	// the shallowest depth of that name.
+	type candidates struct {
+		depth, count, tagged int
+		first, firstTagged   int
+	}
+	names := map[string]*candidates{}
+	for i, f := range fields {
+		if f.remain {
+			continue
+		}
+		c := names[f.name]
+		if c == nil {
+			c = &candidates{depth: len(f.index), first: i, firstTagged: -1}
+			names[f.name] = c
+		}
+		if len(f.index) > c.depth {
+			continue
+		}
+		c.count++
+		if f.tagged {
+			if c.tagged++; c.tagged == 1 {
+				c.firstTagged = i
+			}
+		}
+	}
+	dominant := func(i int, f structField) bool {
+		c := names[f.name]
+		switch {
+		case c.count == 1:
+			return c.first == i
+		case c.tagged == 1:
+			return c.firstTagged == i
+		}
+		return false
+	}
+	resolved := fields[:0]
+	for i, f := range fields {
+		if f.remain || dominant(i, f) {
+			resolved = append(resolved, f)
+		}
+	}
+	sortFieldsByIndex(resolved)
+	return resolved
+}
+
+// declaredFields returns the fields of the struct type t, followed by those
+// AdditionalFields adds to it.
+func (r *Reflector) declaredFields(t reflect.Type) []reflect.StructField {
+	fields := make([]reflect.StructField, t.NumField())
+	for i := range fields {
+		fields[i] = t.Field(i)
+	}
+	if r.AdditionalFields != nil {
+		fields = append(fields, r.AdditionalFields(t)...)
+	}
+	return fields
+}
+
+func sortFieldsByIndex(fields []structField) {
+	// Insertion sort keeps this stable and is fast for the few fields out
+	// of order.
+	for i := 1; i < len(fields); i++ {
+		for j := i; j > 0 && indexLess(fields[j].index, fields[j-1].index); j-- {
+			fields[j], fields[j-1] = fields[j-1], fields[j]
+		}
+	}
+}
+
+func indexLess(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// fieldValue returns the value of f within v, a value of the struct type f
+// was found in, or false if it can't be reached through nil pointers to
+// embedded structs or isn't a field of the struct.
+func (f structField) fieldValue(v reflect.Value) (reflect.Value, bool) {
+	if f.extra {
+		return reflect.Value{}, false
+	}
+	for i, x := range f.index {
+		if i > 0 {
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return reflect.Value{}, false
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// quotable returns whether encoding/json quotes values of the type of a field
+// with the string option: booleans, numbers and strings that don't marshal
+// themselves, possibly behind an unnamed pointer.
+func quotable(t reflect.Type) bool {
+	if t.Name() == "" && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// quotedSchema returns the schema of the JSON strings values of the type t
+// are quoted in.
+func quotedSchema(t reflect.Type) *Type {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Type{Type: "string", Enum: []interface{}{"true", "false"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Type{Type: "string", Pattern: `^-?(0|[1-9][0-9]*)$`}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Type{Type: "string", Pattern: `^(0|[1-9][0-9]*)$`}
+	case reflect.Float32, reflect.Float64:
+		return &Type{Type: "string", Pattern: `^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`}
+	}
+	// Strings are quoted as JSON strings within JSON strings.
+	return &Type{Type: "string", Pattern: `^".*"$`}
+}
+
+// quoteValue returns v, a value decoded from JSON, quoted as the value of a
+// field with the string option is.
+func quoteValue(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	return string(b)
+}
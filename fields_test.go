@@ -0,0 +1,174 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type FieldsLevel1 struct {
+	Name   string
+	Shared string
+	Choice string `json:"Choice"`
+	Dup    string `json:"dup"`
+	FieldsLevel2
+}
+
+type FieldsLevel1B struct {
+	Shared string
+	Choice string
+	Other  string `json:"dup"`
+	Only   int    `json:"only,omitempty"`
+}
+
+type FieldsLevel2 struct {
+	Deep   string `json:"deep"`
+	Shared string
+}
+
+type FieldsCount int
+
+type fieldsHidden struct {
+	Promoted string `json:"promoted"`
+}
+
+// FieldsTaggedEmbed is embedded with a tag without a name, so its fields are
+// promoted as those of untagged embedded structs are.
+type FieldsTaggedEmbed struct {
+	Embedded string `json:"embedded"`
+}
+
+type FieldsRecursive struct {
+	*FieldsRecursive
+	Leaf string `json:"leaf"`
+}
+
+type FieldsJSON struct {
+	FieldsLevel1
+	*FieldsLevel1B
+	FieldsCount
+	fieldsHidden
+	FieldsTaggedEmbed `json:",omitempty"`
+	Name              string           `json:"Name"`
+	Count             int              `json:"count,string" jsonschema:"default=3"`
+	Ratio             float64          `json:"ratio,string"`
+	Enabled           bool             `json:"enabled,string"`
+	Label             string           `json:"label,string"`
+	Ptr               *uint            `json:"ptr,string,omitempty"`
+	When              time.Time        `json:"when,string"`
+	Zero              time.Time        `json:"zero,omitzero"`
+	Inner             *FieldsRecursive `json:"inner,omitempty"`
+}
+
+// jsonKeys returns the keys of the JSON object v marshals to, in order.
+func jsonKeys(t *testing.T, v interface{}) ([]string, map[string]interface{}) {
+	t.Helper()
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	dec := json.NewDecoder(bytes.NewReader(b))
+	_, err = dec.Token()
+	require.NoError(t, err)
+	var keys []string
+	for dec.More() {
+		key, err := dec.Token()
+		require.NoError(t, err)
+		keys = append(keys, key.(string))
+		var value json.RawMessage
+		require.NoError(t, dec.Decode(&value))
+	}
+	values := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(b, &values))
+	return keys, values
+}
+
+func TestStructFieldsMatchEncodingJSON(t *testing.T) {
+	s := Reflect(&FieldsJSON{})
+	def := s.Definitions["FieldsJSON"]
+	ptr := uint(7)
+	full := FieldsJSON{
+		FieldsLevel1:      FieldsLevel1{Name: "hidden", Shared: "a", Choice: "tagged", Dup: "x", FieldsLevel2: FieldsLevel2{Deep: "deep", Shared: "b"}},
+		FieldsLevel1B:     &FieldsLevel1B{Shared: "c", Choice: "untagged", Other: "y", Only: 1},
+		FieldsCount:       2,
+		fieldsHidden:      fieldsHidden{Promoted: "p"},
+		FieldsTaggedEmbed: FieldsTaggedEmbed{Embedded: "e"},
+		Name:              "name",
+		Count:             5,
+		Ratio:             0.25,
+		Enabled:           true,
+		Label:             "label",
+		Ptr:               &ptr,
+		When:              time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Zero:              time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Inner:             &FieldsRecursive{Leaf: "leaf"},
+	}
+
+	// Properties are the keys of all fields, in the same order.
+	keys, values := jsonKeys(t, full)
+	require.Equal(t, keys, propertyNames(def))
+	require.Equal(t, "tagged", values["Choice"])
+	require.Equal(t, "name", values["Name"])
+
+	// Required properties are those of fields that are never omitted.
+	keys, _ = jsonKeys(t, FieldsJSON{FieldsLevel1B: &FieldsLevel1B{}})
+	require.Equal(t, keys, def.Required)
+
+	// Quoted values are strings matching their schemas.
+	properties := propertyMap(def)
+	for _, name := range []string{"count", "ratio", "enabled", "label", "ptr"} {
+		p := properties[name]
+		require.Equal(t, "string", p.Type, name)
+		value, ok := values[name].(string)
+		require.True(t, ok, name)
+		if p.Pattern != "" {
+			require.Regexp(t, regexp.MustCompile(p.Pattern), value, name)
+		} else {
+			require.Contains(t, p.Enum, value, name)
+		}
+	}
+	require.Equal(t, "3", properties["count"].Default)
+	// time.Time marshals itself, so isn't quoted.
+	require.Equal(t, "date-time", properties["when"].Format)
+
+	// Recursive embedding stops at the first struct seen.
+	require.Equal(t, []string{"leaf"}, propertyNames(s.Definitions["FieldsRecursive"]))
+}
+
+func TestStructFieldsDefaults(t *testing.T) {
+	v := &FieldsJSON{FieldsLevel1B: &FieldsLevel1B{}}
+	require.NoError(t, ApplyDefaults(v))
+	require.Equal(t, 3, v.Count)
+
+	s := ReflectWithDefaults(&FieldsJSON{Count: 5, Ratio: 0.5, FieldsLevel1: FieldsLevel1{Name: "hidden"}})
+	properties := propertyMap(s.Definitions["FieldsJSON"])
+	require.Equal(t, "5", properties["count"].Default)
+	require.Equal(t, "0.5", properties["ratio"].Default)
+	// The hidden field doesn't give the default of the field hiding it.
+	require.Nil(t, properties["Name"].Default)
+}
+
+func TestStructFieldsIndex(t *testing.T) {
+	r := &Reflector{}
+	fields := r.structFields(reflect.TypeOf(FieldsJSON{}))
+	v := reflect.ValueOf(FieldsJSON{fieldsHidden: fieldsHidden{Promoted: "p"}})
+	for _, f := range fields {
+		if f.name != "promoted" {
+			continue
+		}
+		fv, ok := f.fieldValue(v)
+		require.True(t, ok)
+		require.Equal(t, "p", fv.String())
+	}
+
+	// Fields of nil embedded pointers can't be reached.
+	for _, f := range fields {
+		if f.name == "only" {
+			_, ok := f.fieldValue(v)
+			require.False(t, ok)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+export interface GithubComAlecthomasJsonschemaTSContact {
+  name: string;
+  kind: "person" | "company";
+  priority?: 1 | 2 | 3;
+  address?: GithubComAlecthomasJsonschemaTSAddress | null;
+  previous?: GithubComAlecthomasJsonschemaTSAddress[];
+  scores?: Record<string, number>;
+  groups?: Record<string, string[]>;
+  extra?: unknown;
+  /**
+   * Deprecated.
+   * Use name instead.
+   */
+  "legacy-id"?: boolean;
+  inline: Definition;
+}
+
+export interface GithubComAlecthomasJsonschemaTSAddress {
+  /** Street and number */
+  street: string;
+  city?: string;
+}
+
+export interface Definition {
+  note: string;
+}
@@ -0,0 +1,25 @@
+export type RootOneOf = {
+  field1?: string;
+  field2?: string;
+  field3?: string | unknown[];
+  field4?: string;
+  child?: ChildOneOf;
+} & ({
+  field1: string;
+  field4: string;
+} | {
+  field2: string;
+});
+
+export type ChildOneOf = {
+  child1?: string;
+  child2?: string;
+  child3?: string | unknown[];
+  child4?: string;
+} & ({
+  child1: string;
+  child4: string;
+} | {
+  child2: string;
+  child3: string | unknown[];
+});
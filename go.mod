@@ -5,4 +5,5 @@ go 1.12
 require (
 	github.com/iancoleman/orderedmap v0.0.0-20190318233801-ac98e3ecb4b0
 	github.com/stretchr/testify v1.3.1-0.20190311161405-34c6fa2dc709
+	gopkg.in/yaml.v3 v3.0.1
 )
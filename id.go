@@ -0,0 +1,75 @@
+package jsonschema
+
+import (
+	"net/url"
+	"path"
+	"reflect"
+	"strings"
+)
+
+// typeID returns the ID of the document holding the schema of t. It is
+// relative to BaseSchemaID, unless TypeIDer returned an absolute URI that
+// isn't under it.
+func (r *Reflector) typeID(t reflect.Type) string {
+	var id string
+	if r.TypeIDer != nil {
+		id = r.TypeIDer(t)
+	}
+	if id == "" {
+		id = path.Join(t.PkgPath(), t.Name()) + ".json"
+	}
+	if base := r.baseSchemaID(); base != "" && strings.HasPrefix(id, base) {
+		id = strings.TrimPrefix(id, base)
+	}
+	return id
+}
+
+// schemaID resolves id against BaseSchemaID.
+func (r *Reflector) schemaID(id string) string {
+	base, err := url.Parse(r.baseSchemaID())
+	if err != nil {
+		return id
+	}
+	ref, err := url.Parse(id)
+	if err != nil {
+		return id
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// baseSchemaID returns BaseSchemaID with a trailing slash, so that IDs are
+// resolved beneath it rather than next to it.
+func (r *Reflector) baseSchemaID() string {
+	if r.BaseSchemaID == "" || strings.HasSuffix(r.BaseSchemaID, "/") {
+		return r.BaseSchemaID
+	}
+	return r.BaseSchemaID + "/"
+}
+
+func isAbsoluteURI(id string) bool {
+	u, err := url.Parse(id)
+	return err == nil && u.IsAbs()
+}
+
+// relativeRef returns a URI reference to the document to, relative to the
+// document from. Both are paths relative to the same base, unless to is an
+// absolute URI in which case it is returned as is.
+func relativeRef(from, to string) string {
+	if isAbsoluteURI(to) {
+		return to
+	}
+	var fromDir []string
+	if dir := path.Dir(from); dir != "." {
+		fromDir = strings.Split(dir, "/")
+	}
+	toParts := strings.Split(to, "/")
+	common := 0
+	for common < len(fromDir) && common < len(toParts)-1 && fromDir[common] == toParts[common] {
+		common++
+	}
+	var parts []string
+	for i := common; i < len(fromDir); i++ {
+		parts = append(parts, "..")
+	}
+	return strings.Join(append(parts, toParts[common:]...), "/")
+}
@@ -0,0 +1,379 @@
+// Package jsonschematest provides helpers for testing the schemas generated
+// by github.com/alecthomas/jsonschema against golden files.
+//
+// Golden files are rewritten with the generated schemas, rather than
+// compared, when tests are run with the -update flag:
+//
+//	go test ./... -update
+//
+// or, for packages whose tests don't all import this one, with the
+// JSONSCHEMA_UPDATE_GOLDEN environment variable set to true:
+//
+//	JSONSCHEMA_UPDATE_GOLDEN=1 go test ./...
+package jsonschematest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/iancoleman/orderedmap"
+
+	"github.com/alecthomas/jsonschema"
+)
+
+// UpdateFlag is the flag which rewrites golden files with the generated
+// schemas. It is registered unless a package initialised earlier already
+// defined a flag of that name, in which case that flag is used instead.
+const UpdateFlag = "update"
+
+// UpdateEnv is the environment variable which, when set to true, rewrites
+// golden files like UpdateFlag. Unlike the flag, it can be given to the
+// tests of several packages at once, whether or not they define it.
+const UpdateEnv = "JSONSCHEMA_UPDATE_GOLDEN"
+
+func init() {
+	if flag.Lookup(UpdateFlag) == nil {
+		flag.Bool(UpdateFlag, false, "rewrite golden files with the generated schemas")
+	}
+}
+
+func update() bool {
+	if f := flag.Lookup(UpdateFlag); f != nil {
+		if v, err := strconv.ParseBool(f.Value.String()); err == nil && v {
+			return true
+		}
+	}
+	v, _ := strconv.ParseBool(os.Getenv(UpdateEnv))
+	return v
+}
+
+// maxDifferences limits how many differences are reported for a mismatch.
+const maxDifferences = 20
+
+// AssertGolden reflects v using r and checks that the result matches the
+// schema in the golden file at path. See AssertGoldenSchema.
+func AssertGolden(t testing.TB, r *jsonschema.Reflector, v interface{}, path string) {
+	t.Helper()
+	AssertGoldenSchema(t, r.Reflect(v), path)
+}
+
+// AssertGoldenSchema checks that s matches the schema in the golden file at
+// path, reporting each difference between them by its JSON pointer. Both are
+// compared as decoded schemas, so that formatting, key order and escaping
+// differences such as "\/" don't matter.
+//
+// With UpdateFlag or UpdateEnv set the golden file is written instead, if it
+// differs.
+func AssertGoldenSchema(t testing.TB, s *jsonschema.Schema, path string) {
+	t.Helper()
+	actual, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		t.Fatalf("jsonschematest: marshalling schema: %s", err)
+		return
+	}
+	actual = append(actual, '\n')
+
+	update := update()
+	expected, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !update {
+		t.Fatalf("jsonschematest: golden file %s does not exist, run the test with -%s to create it", path, UpdateFlag)
+		return
+	} else if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("jsonschematest: %s", err)
+		return
+	}
+
+	var differences []string
+	if err == nil {
+		differences, err = Compare(expected, actual)
+		if err != nil {
+			t.Fatalf("jsonschematest: %s: %s", path, err)
+			return
+		}
+		if len(differences) == 0 {
+			return
+		}
+	}
+
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("jsonschematest: %s", err)
+			return
+		}
+		if err := ioutil.WriteFile(path, actual, 0644); err != nil {
+			t.Fatalf("jsonschematest: %s", err)
+		}
+		return
+	}
+
+	if len(differences) > maxDifferences {
+		differences = append(differences[:maxDifferences], fmt.Sprintf("... and %d more", len(differences)-maxDifferences))
+	}
+	t.Errorf("jsonschematest: schema does not match golden file %s (run the test with -%s to rewrite it):\n\t%s",
+		path, UpdateFlag, strings.Join(differences, "\n\t"))
+}
+
+// Compare decodes two JSON schemas and returns their differences, one per
+// line, as the JSON pointers of the differing keywords followed by the
+// expected and actual values. The schemas are walked keyword by keyword, down
+// into their subschemas, so that a difference deep in a tree of Types is
+// reported where it is rather than as a difference of the whole tree. Key
+// order is ignored, array order isn't.
+func Compare(expected, actual []byte) ([]string, error) {
+	e, err := decode(expected)
+	if err != nil {
+		return nil, fmt.Errorf("decoding expected schema: %w", err)
+	}
+	a, err := decode(actual)
+	if err != nil {
+		return nil, fmt.Errorf("decoding actual schema: %w", err)
+	}
+	var differences []string
+	compareTypes("", e, a, &differences)
+	sort.Strings(differences)
+	return differences, nil
+}
+
+func decode(b []byte) (*jsonschema.Type, error) {
+	s := &jsonschema.Schema{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	s.Type.Definitions = s.Definitions
+	return s.Type, nil
+}
+
+var (
+	typeType       = reflect.TypeOf(jsonschema.Type{})
+	typePtrType    = reflect.TypeOf(&jsonschema.Type{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	orderedMapType = reflect.TypeOf(&orderedmap.OrderedMap{})
+)
+
+// compareTypes appends the differences between the keywords of two schemas.
+func compareTypes(ptr string, expected, actual *jsonschema.Type, differences *[]string) {
+	if expected == nil || actual == nil {
+		compareValues(ptr, expected, actual, differences)
+		return
+	}
+	e, a := reflect.ValueOf(expected).Elem(), reflect.ValueOf(actual).Elem()
+	for i := 0; i < typeType.NumField(); i++ {
+		name := strings.Split(typeType.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || name == "type" {
+			continue
+		}
+		child := ptr + "/" + escapePointer(name)
+		ef, af := e.Field(i), a.Field(i)
+		switch {
+		case ef.Type() == typePtrType:
+			compareTypes(child, ef.Interface().(*jsonschema.Type), af.Interface().(*jsonschema.Type), differences)
+		case ef.Type() == rawMessageType:
+			compareRaw(child, ef.Interface().(json.RawMessage), af.Interface().(json.RawMessage), differences)
+		case ef.Type() == orderedMapType:
+			compareTypeMaps(child, orderedTypes(ef.Interface().(*orderedmap.OrderedMap)), orderedTypes(af.Interface().(*orderedmap.OrderedMap)), differences)
+		case ef.Kind() == reflect.Map && ef.Type().Elem() == typePtrType:
+			compareTypeMaps(child, typeMap(ef), typeMap(af), differences)
+		case ef.Kind() == reflect.Slice && ef.Type().Elem() == typePtrType && ef.Len() == af.Len():
+			for j := 0; j < ef.Len(); j++ {
+				compareTypes(child+"/"+strconv.Itoa(j), ef.Index(j).Interface().(*jsonschema.Type), af.Index(j).Interface().(*jsonschema.Type), differences)
+			}
+		default:
+			compareValues(child, present(ef), present(af), differences)
+		}
+	}
+	compareValues(ptr+"/type", typeKeyword(expected), typeKeyword(actual), differences)
+	compare(ptr, expected.Extras, actual.Extras, differences)
+}
+
+// compareTypeMaps appends the differences between two maps of schemas.
+func compareTypeMaps(ptr string, expected, actual map[string]*jsonschema.Type, differences *[]string) {
+	keys := map[string]bool{}
+	for key := range expected {
+		keys[key] = true
+	}
+	for key := range actual {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	for _, key := range sorted {
+		compareTypes(ptr+"/"+escapePointer(key), expected[key], actual[key], differences)
+	}
+}
+
+// compareRaw appends the differences between two keywords that hold either
+// a schema or another value, such as additionalProperties.
+func compareRaw(ptr string, expected, actual json.RawMessage, differences *[]string) {
+	e, a := rawValue(expected), rawValue(actual)
+	et, eok := e.(*jsonschema.Type)
+	at, aok := a.(*jsonschema.Type)
+	if eok && aok {
+		compareTypes(ptr, et, at, differences)
+		return
+	}
+	compareValues(ptr, e, a, differences)
+}
+
+// compareValues appends the difference between two values, either of which
+// is nil if it is missing.
+func compareValues(ptr string, expected, actual interface{}, differences *[]string) {
+	switch {
+	case isNil(expected) && isNil(actual):
+	case isNil(actual):
+		*differences = append(*differences, fmt.Sprintf("#%s: missing, expected %s", ptr, format(expected)))
+	case isNil(expected):
+		*differences = append(*differences, fmt.Sprintf("#%s: unexpected %s", ptr, format(actual)))
+	case format(expected) != format(actual):
+		*differences = append(*differences, fmt.Sprintf("#%s: expected %s, got %s", ptr, format(expected), format(actual)))
+	}
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// present returns the value of a keyword, or nil if it is omitted.
+func present(v reflect.Value) interface{} {
+	if v.IsZero() || ((v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0) {
+		return nil
+	}
+	return v.Interface()
+}
+
+// typeKeyword returns the value of the type keyword of t, a list if it
+// allows several types.
+func typeKeyword(t *jsonschema.Type) interface{} {
+	if len(t.AdditionalTypes) > 0 {
+		return append([]string{t.Type}, t.AdditionalTypes...)
+	}
+	if t.Type == "" {
+		return nil
+	}
+	return t.Type
+}
+
+// rawValue decodes a keyword held as raw JSON, to a schema if it is an
+// object.
+func rawValue(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		t := &jsonschema.Type{}
+		if err := json.Unmarshal(raw, t); err == nil {
+			return t
+		}
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	return v
+}
+
+func orderedTypes(m *orderedmap.OrderedMap) map[string]*jsonschema.Type {
+	if m == nil {
+		return nil
+	}
+	types := map[string]*jsonschema.Type{}
+	for _, key := range m.Keys() {
+		v, _ := m.Get(key)
+		t, _ := v.(*jsonschema.Type)
+		types[key] = t
+	}
+	return types
+}
+
+func typeMap(v reflect.Value) map[string]*jsonschema.Type {
+	types := map[string]*jsonschema.Type{}
+	for _, key := range v.MapKeys() {
+		types[key.String()] = v.MapIndex(key).Interface().(*jsonschema.Type)
+	}
+	return types
+}
+
+// compare appends the differences between two decoded JSON values, such as
+// keywords that Type doesn't know.
+func compare(ptr string, expected, actual interface{}, differences *[]string) {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(e, a) {
+			ev, inExpected := e[key]
+			av, inActual := a[key]
+			child := ptr + "/" + escapePointer(key)
+			switch {
+			case !inActual:
+				*differences = append(*differences, fmt.Sprintf("#%s: missing, expected %s", child, format(ev)))
+			case !inExpected:
+				*differences = append(*differences, fmt.Sprintf("#%s: unexpected %s", child, format(av)))
+			default:
+				compare(child, ev, av, differences)
+			}
+		}
+		return
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			break
+		}
+		for i := range e {
+			compare(ptr+"/"+strconv.Itoa(i), e[i], a[i], differences)
+		}
+		return
+	}
+	if format(expected) != format(actual) {
+		*differences = append(*differences, fmt.Sprintf("#%s: expected %s, got %s", ptr, format(expected), format(actual)))
+	}
+}
+
+func sortedKeys(maps ...map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// format returns v as compact JSON, without escaping HTML characters so
+// that values read as they were written.
+func format(v interface{}) string {
+	b := &bytes.Buffer{}
+	e := json.NewEncoder(b)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
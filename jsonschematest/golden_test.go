@@ -0,0 +1,137 @@
+package jsonschematest
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/jsonschema"
+	"github.com/alecthomas/jsonschema/examples"
+)
+
+// recorder captures the failures reported to it instead of failing the
+// test.
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+type Shape struct {
+	Name   string  `json:"name" jsonschema:"title=Name of the shape"`
+	Sides  int     `json:"sides" jsonschema:"minimum=3"`
+	Colour string  `json:"colour,omitempty" jsonschema:"enum=red,enum=green"`
+	Scale  float64 `json:"scale,omitempty"`
+}
+
+func TestAssertGolden(t *testing.T) {
+	AssertGolden(t, &jsonschema.Reflector{}, &examples.User{}, "fixtures/user.json")
+	AssertGolden(t, &jsonschema.Reflector{}, &Shape{}, "fixtures/shape.json")
+}
+
+func TestAssertGoldenEscaping(t *testing.T) {
+	// The fixture spells "/" as "\/" and orders its keys differently.
+	AssertGolden(t, &jsonschema.Reflector{}, &Shape{}, "fixtures/shape_escaped.json")
+}
+
+func TestAssertGoldenMismatch(t *testing.T) {
+	r := &recorder{TB: t}
+	AssertGolden(r, &jsonschema.Reflector{AllowAdditionalProperties: true, RequiredFromJSONSchemaTags: true}, &Shape{}, "fixtures/shape.json")
+	require.False(t, r.fatal)
+	require.Len(t, r.errors, 1)
+	require.Contains(t, r.errors[0], "schema does not match golden file fixtures/shape.json")
+	require.Contains(t, r.errors[0], "\t#/definitions/Shape/additionalProperties: expected false, got true\n")
+	require.Contains(t, r.errors[0], "\t#/definitions/Shape/required: missing, expected [\"name\",\"sides\"]")
+}
+
+func TestAssertGoldenMissing(t *testing.T) {
+	r := &recorder{TB: t}
+	AssertGolden(r, &jsonschema.Reflector{}, &Shape{}, filepath.Join(t.TempDir(), "missing.json"))
+	require.True(t, r.fatal)
+	require.Contains(t, r.errors[0], "run the test with -update to create it")
+}
+
+func TestAssertGoldenUpdate(t *testing.T) {
+	for name, enable := range map[string]func(t *testing.T){
+		"Flag": func(t *testing.T) {
+			require.NoError(t, flag.Set(UpdateFlag, "true"))
+			t.Cleanup(func() { require.NoError(t, flag.Set(UpdateFlag, "false")) })
+		},
+		"Env": func(t *testing.T) {
+			t.Setenv(UpdateEnv, "1")
+		},
+	} {
+		enable := enable
+		t.Run(name, func(t *testing.T) {
+			enable(t)
+
+			path := filepath.Join(t.TempDir(), "schemas", "shape.json")
+			r := &recorder{TB: t}
+			AssertGolden(r, &jsonschema.Reflector{}, &Shape{}, path)
+			require.Empty(t, r.errors)
+
+			actual, err := ioutil.ReadFile(path)
+			require.NoError(t, err)
+			expected, err := ioutil.ReadFile("fixtures/shape.json")
+			require.NoError(t, err)
+			require.Equal(t, string(expected), string(actual))
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	differences, err := Compare(
+		[]byte(`{
+			"type": "object",
+			"properties": {
+				"a/b": {"type": "integer", "minimum": 1},
+				"gone": {"type": "string"}
+			},
+			"required": ["a/b"],
+			"additionalProperties": {"type": "string"},
+			"enum": [1, 2],
+			"x-extra": {"k": 1, "n": 1.0}
+		}`),
+		[]byte(`{
+			"properties": {
+				"a/b": {"minimum": 2, "type": "integer"},
+				"added": {"type": "string", "maxLength": 5}
+			},
+			"type": ["object", "null"],
+			"required": ["a/b"],
+			"additionalProperties": {"type": "number"},
+			"enum": [1],
+			"x-extra": {"k": 2, "n": 1}
+		}`),
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`#/additionalProperties/type: expected "string", got "number"`,
+		`#/enum: expected [1,2], got [1]`,
+		`#/properties/added: unexpected {"maxLength":5,"type":"string"}`,
+		`#/properties/a~1b/minimum: expected 1, got 2`,
+		`#/properties/gone: missing, expected {"type":"string"}`,
+		`#/type: expected "object", got ["object","null"]`,
+		`#/x-extra/k: expected 1, got 2`,
+	}, differences)
+
+	_, err = Compare([]byte(`{`), []byte(`{}`))
+	require.Error(t, err)
+	require.True(t, strings.HasPrefix(err.Error(), "decoding expected schema"))
+}
@@ -0,0 +1,69 @@
+package jsonschema
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SnakeCase is a KeyNamer that names properties like "user_id" after fields
+// like UserID.
+func SnakeCase(name string) string {
+	return strings.Join(lowerWords(name), "_")
+}
+
+// KebabCase is a KeyNamer that names properties like "user-id" after fields
+// like UserID.
+func KebabCase(name string) string {
+	return strings.Join(lowerWords(name), "-")
+}
+
+// CamelCase is a KeyNamer that names properties like "userId" after fields
+// like UserID.
+func CamelCase(name string) string {
+	words := lowerWords(name)
+	for i := 1; i < len(words); i++ {
+		words[i] = upperFirst(words[i])
+	}
+	return strings.Join(words, "")
+}
+
+// LowerCase is a KeyNamer that names properties like "userid" after fields
+// like UserID, as gopkg.in/yaml.v2 does.
+func LowerCase(name string) string {
+	return strings.ToLower(name)
+}
+
+// lowerWords returns the lowercased words of the Go identifier name. Words
+// start at each upper case letter following a lower case letter or digit, and
+// at the last letter of a run of upper case letters followed by a lower case
+// one, so that acronyms such as "HTTP" in "HTTPServer" make a single word.
+// Underscores separate words too.
+func lowerWords(name string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+	runes := []rune(name)
+	for i, r := range runes {
+		if r == '_' {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, unicode.ToLower(r))
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
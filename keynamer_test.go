@@ -0,0 +1,50 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyNamers(t *testing.T) {
+	tests := []struct {
+		name                       string
+		snake, kebab, camel, lower string
+	}{
+		{"Name", "name", "name", "name", "name"},
+		{"UserID", "user_id", "user-id", "userId", "userid"},
+		{"HTTPServer", "http_server", "http-server", "httpServer", "httpserver"},
+		{"ID", "id", "id", "id", "id"},
+		{"Base64Data", "base64_data", "base64-data", "base64Data", "base64data"},
+		{"Max_Size", "max_size", "max-size", "maxSize", "max_size"},
+		{"ÄrgerLevel", "ärger_level", "ärger-level", "ärgerLevel", "ärgerlevel"},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.snake, SnakeCase(test.name), test.name)
+		require.Equal(t, test.kebab, KebabCase(test.name), test.name)
+		require.Equal(t, test.camel, CamelCase(test.name), test.name)
+		require.Equal(t, test.lower, LowerCase(test.name), test.name)
+	}
+}
+
+type KeyNamed struct {
+	UserID    string `jsonschema:"oneof_required=byID"`
+	EmailAddr string `jsonschema:"oneof_required=byEmail"`
+	Nickname  string `json:",omitempty"`
+	Tagged    string `json:"TaggedName"`
+	Skipped   string `json:"-"`
+}
+
+func TestKeyNamer(t *testing.T) {
+	r := &Reflector{KeyNamer: SnakeCase}
+	s := r.Reflect(&KeyNamed{}).Definitions["KeyNamed"]
+	require.Equal(t, []string{"user_id", "email_addr", "nickname", "TaggedName"}, propertyNames(s))
+	require.Equal(t, []string{"user_id", "email_addr", "TaggedName"}, s.Required)
+	require.Len(t, s.OneOf, 2)
+	require.Equal(t, []string{"user_id"}, s.OneOf[0].Required)
+	require.Equal(t, []string{"email_addr"}, s.OneOf[1].Required)
+
+	r.KeyNamer = CamelCase
+	s = r.Reflect(&KeyNamed{}).Definitions["KeyNamed"]
+	require.Equal(t, []string{"userId", "emailAddr", "nickname", "TaggedName"}, propertyNames(s))
+}
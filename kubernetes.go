@@ -0,0 +1,478 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kubernetes extensions to OpenAPI v3 schemas.
+const (
+	kubernetesNullable              = "nullable"
+	kubernetesPreserveUnknownFields = "x-kubernetes-preserve-unknown-fields"
+	kubernetesIntOrString           = "x-kubernetes-int-or-string"
+	kubernetesListType              = "x-kubernetes-list-type"
+	kubernetesListMapKeys           = "x-kubernetes-list-map-keys"
+)
+
+// intOrStringTypes are Kubernetes types that marshal as either a string or
+// an integer.
+var intOrStringTypes = map[string]bool{
+	"k8s.io/apimachinery/pkg/util/intstr.IntOrString": true,
+	"k8s.io/apimachinery/pkg/api/resource.Quantity":   true,
+}
+
+// ReflectStructural reflects v into a Kubernetes structural schema, as
+// required by the openAPIV3Schema of a CustomResourceDefinition. See
+// Structural.
+func (r *Reflector) ReflectStructural(v interface{}) (*Type, error) {
+	return Structural(r.Reflect(v))
+}
+
+// Structural converts s into a Kubernetes structural schema, as required by
+// the openAPIV3Schema of a CustomResourceDefinition:
+//
+//   - references are inlined, keeping the description, title and default
+//     given alongside them, and recursive types are an error;
+//   - alternatives of type null are replaced by "nullable";
+//   - a choice of string or integer becomes "x-kubernetes-int-or-string";
+//   - schemas that accept any value, such as those of json.RawMessage and
+//     interface fields, and objects with additionalProperties true, get
+//     "x-kubernetes-preserve-unknown-fields";
+//   - additionalProperties false is dropped, as Kubernetes prunes unknown
+//     fields instead, and maps become additionalProperties schemas;
+//   - uniqueItems becomes "x-kubernetes-list-type: set" for lists of
+//     scalars, and is dropped otherwise;
+//   - keywords that Kubernetes doesn't support, such as "$schema" and
+//     "readOnly", are removed, the first of any examples becomes "example",
+//     and base64 encoded strings have the format "byte".
+//
+// List types and map keys can be given by the listType and listMapKeys tags,
+// e.g. `jsonschema:"listType=map,listMapKeys=name;protocol"`.
+//
+// The result is checked with ValidateStructural, and its error returned if
+// the schema can't be made structural, e.g. because a field is given
+// different types by a oneOf.
+func Structural(s *Schema) (*Type, error) {
+	c := &structuralConverter{schema: s, inlining: map[string]bool{}}
+	t := c.convert("", s.Type)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if err := ValidateStructural(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+type structuralConverter struct {
+	schema   *Schema
+	inlining map[string]bool
+	err      error
+}
+
+func (c *structuralConverter) convert(ptr string, t *Type) *Type {
+	if c.err != nil || t == nil {
+		return t
+	}
+	for {
+		if t.Ref != "" {
+			name := strings.TrimPrefix(t.Ref, definitionsPrefix)
+			def, ok := c.schema.Definitions[name]
+			switch {
+			case !ok || name == t.Ref:
+				c.err = fmt.Errorf("jsonschema: #%s: cannot inline reference %q", ptr, t.Ref)
+				return t
+			case c.inlining[name]:
+				c.err = fmt.Errorf("jsonschema: #%s: recursive definition %q cannot be inlined into a structural schema", ptr, name)
+				return t
+			}
+			c.inlining[name] = true
+			defer delete(c.inlining, name)
+			t = overlay(def, t)
+			continue
+		}
+		next := c.restructure(t)
+		if next == t {
+			break
+		}
+		t = next
+	}
+	t = c.clean(t)
+	return t.mapSubschemas(func(sub string, s *Type) *Type {
+		return c.convert(ptr+sub, s)
+	})
+}
+
+// overlay returns a copy of def with the annotations given by t, which
+// refers to or wraps def.
+func overlay(def, t *Type) *Type {
+	o := *def
+	o.Version, o.ID = "", ""
+	if t.Title != "" {
+		o.Title = t.Title
+	}
+	if t.Description != "" {
+		o.Description = t.Description
+	}
+	if t.Default != nil {
+		o.Default = t.Default
+	}
+	if len(t.Extras) > 0 {
+		o.Extras = copyExtras(def.Extras)
+		for k, v := range t.Extras {
+			o.Extras[k] = v
+		}
+	}
+	return &o
+}
+
+func copyExtras(extras map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(extras)+1)
+	for k, v := range extras {
+		c[k] = v
+	}
+	return c
+}
+
+// withExtra returns a copy of t with the extra keyword key set to v.
+func withExtra(t *Type, key string, v interface{}) *Type {
+	c := *t
+	c.Extras = copyExtras(t.Extras)
+	c.Extras[key] = v
+	return &c
+}
+
+func hasExtra(t *Type, key string) bool {
+	v, ok := t.Extras[key]
+	return ok && (v == true || v == "true")
+}
+
+// restructure returns the structural equivalent of the combinations and
+// catch-all schemas that t may be, or t itself if it is neither.
+func (c *structuralConverter) restructure(t *Type) *Type {
+	if u := nonNullAlternative(t); u != nil && len(t.AdditionalTypes) > 0 {
+		return withExtra(u, kubernetesNullable, true)
+	}
+	if t.Type == "" && (len(t.OneOf) > 0) != (len(t.AnyOf) > 0) && len(t.AllOf) == 0 && t.Not == nil {
+		alternatives := t.OneOf
+		if len(t.AnyOf) > 0 {
+			alternatives = t.AnyOf
+		}
+		var types []*Type
+		for _, a := range alternatives {
+			if a != nil && a.Type != "null" {
+				types = append(types, a)
+			}
+		}
+
+		if len(types) < len(alternatives) {
+			if len(types) == 1 {
+				u := *t
+				u.OneOf, u.AnyOf = nil, nil
+				return withExtra(overlay(types[0], &u), kubernetesNullable, true)
+			}
+			u := withExtra(t, kubernetesNullable, true)
+			if len(t.OneOf) > 0 {
+				u.OneOf = types
+			} else {
+				u.AnyOf = types
+			}
+			return u
+		}
+
+		if len(types) == 2 && isBareType(types[0]) && isBareType(types[1]) &&
+			stringSet([]string{types[0].Type, types[1].Type})["string"] &&
+			stringSet([]string{types[0].Type, types[1].Type})["integer"] {
+			u := withExtra(t, kubernetesIntOrString, true)
+			u.OneOf, u.AnyOf = nil, nil
+			return u
+		}
+	}
+
+	if t.Type == "" && t.Properties == nil && t.Items == nil && len(t.PatternProperties) == 0 &&
+		len(t.Enum) == 0 && len(t.OneOf)+len(t.AnyOf)+len(t.AllOf) == 0 && t.Not == nil &&
+		additionalPropertiesSchema(t) == nil && !hasExtra(t, kubernetesPreserveUnknownFields) &&
+		!hasExtra(t, kubernetesIntOrString) {
+		u := withExtra(t, kubernetesPreserveUnknownFields, true)
+		u.AdditionalProperties = nil
+		return u
+	}
+	return t
+}
+
+// isBareType reports whether t gives nothing but a type.
+func isBareType(t *Type) bool {
+	return t.Type != "" && schemaKey(t) == schemaKey(&Type{Type: t.Type})
+}
+
+// clean returns a copy of t without the keywords that structural schemas
+// don't allow.
+func (c *structuralConverter) clean(t *Type) *Type {
+	u := *t
+	u.Version, u.ID, u.Definitions = "", "", nil
+	u.ReadOnly, u.WriteOnly = false, false
+
+	if len(u.PatternProperties) == 1 && additionalPropertiesSchema(&u) == nil {
+		for _, values := range u.PatternProperties {
+			b, err := json.Marshal(values)
+			if err != nil {
+				c.err = err
+				return t
+			}
+			u.AdditionalProperties = b
+		}
+		u.PatternProperties = nil
+		if u.Type == "" {
+			u.Type = "object"
+		}
+	}
+	if ap := strings.TrimSpace(string(u.AdditionalProperties)); ap == "false" || ap == "true" {
+		u.AdditionalProperties = nil
+		if ap == "true" && !hasExtra(&u, kubernetesPreserveUnknownFields) {
+			u = *withExtra(&u, kubernetesPreserveUnknownFields, true)
+		}
+	}
+
+	if u.UniqueItems {
+		u.UniqueItems = false
+		if _, ok := u.Extras[kubernetesListType]; !ok {
+			items := resolveRef(c.schema, u.Items)
+			if items != nil && items.Type != "object" && items.Type != "array" && items.Type != "" {
+				u = *withExtra(&u, kubernetesListType, "set")
+			}
+		}
+	}
+
+	if len(u.Examples) > 0 {
+		u = *withExtra(&u, "example", u.Examples[0])
+		u.Examples = nil
+	}
+	if (u.Media != nil && u.Media.BinaryEncoding == "base64") || u.BinaryEncoding == "base64" {
+		u.Media, u.BinaryEncoding = nil, ""
+		if u.Format == "" {
+			u.Format = "byte"
+		}
+	}
+	return &u
+}
+
+// A StructuralError lists the ways in which a schema is not a Kubernetes
+// structural schema.
+type StructuralError struct {
+	// Violations describe each rule broken, prefixed by the JSON Pointer to
+	// the schema breaking it.
+	Violations []string
+}
+
+func (e *StructuralError) Error() string {
+	return "jsonschema: schema is not structural: " + strings.Join(e.Violations, "; ")
+}
+
+// ValidateStructural checks that t is a Kubernetes structural schema,
+// returning a *StructuralError listing the violations if it isn't. Besides
+// the structural rules, which require every schema outside allOf, anyOf,
+// oneOf and not to have a type, and the schemas within them to only
+// constrain fields specified outside of them, it checks for keywords that
+// Kubernetes doesn't support and for consistent list types.
+func ValidateStructural(t *Type) error {
+	v := &structuralValidator{}
+	v.validate("", t, false)
+	if len(v.violations) > 0 {
+		return &StructuralError{Violations: v.violations}
+	}
+	return nil
+}
+
+type structuralValidator struct {
+	violations []string
+}
+
+func (v *structuralValidator) violation(ptr, format string, args ...interface{}) {
+	v.violations = append(v.violations, fmt.Sprintf("#%s: %s", ptr, fmt.Sprintf(format, args...)))
+}
+
+func (v *structuralValidator) validate(ptr string, t *Type, inJunctor bool) {
+	if t == nil {
+		return
+	}
+	unsupported := map[string]bool{
+		"$ref":              t.Ref != "",
+		"$schema":           t.Version != "",
+		"id":                t.ID != "",
+		"definitions":       len(t.Definitions) > 0,
+		"patternProperties": len(t.PatternProperties) > 0,
+		"additionalItems":   t.AdditionalItems != nil,
+		"uniqueItems":       t.UniqueItems,
+	}
+	for _, keyword := range sortedKeys(unsupported) {
+		if unsupported[keyword] {
+			v.violation(ptr, "%s is not supported", keyword)
+		}
+	}
+
+	additional := strings.TrimSpace(string(t.AdditionalProperties))
+	switch {
+	case additional == "false":
+		v.violation(ptr, "additionalProperties must not be false")
+	case additional == "true":
+		v.violation(ptr, "additionalProperties must not be true, use %s instead", kubernetesPreserveUnknownFields)
+	case additional != "" && len(propertyNames(t)) > 0:
+		v.violation(ptr, "additionalProperties and properties are mutually exclusive")
+	}
+
+	preserve, intOrString := hasExtra(t, kubernetesPreserveUnknownFields), hasExtra(t, kubernetesIntOrString)
+	if inJunctor {
+		forbidden := map[string]bool{
+			"type":                          t.Type != "",
+			"description":                   t.Description != "",
+			"default":                       t.Default != nil,
+			"additionalProperties":          additional != "",
+			kubernetesNullable:              hasExtra(t, kubernetesNullable),
+			kubernetesPreserveUnknownFields: preserve,
+			kubernetesIntOrString:           intOrString,
+		}
+		for _, keyword := range sortedKeys(forbidden) {
+			if forbidden[keyword] {
+				v.violation(ptr, "%s must not be specified within allOf, anyOf, oneOf or not", keyword)
+			}
+		}
+	} else if t.Type == "" && !preserve && !intOrString {
+		v.violation(ptr, "type must be specified")
+	}
+	if len(t.AdditionalTypes) > 0 {
+		v.violation(ptr, "type must be a single type")
+	}
+	v.validateListType(ptr, t)
+
+	properties := propertyMap(t)
+	for _, name := range propertyNames(t) {
+		v.validate(ptr+"/properties/"+escapePointer(name), properties[name], inJunctor)
+	}
+	v.validate(ptr+"/items", t.Items, inJunctor)
+	v.validate(ptr+"/additionalProperties", additionalPropertiesSchema(t), inJunctor)
+
+	for _, junctor := range junctors(ptr, t) {
+		v.specifiedOutside(junctor.ptr, t, junctor.t)
+		v.validate(junctor.ptr, junctor.t, true)
+	}
+}
+
+func (v *structuralValidator) validateListType(ptr string, t *Type) {
+	listType, hasListType := t.Extras[kubernetesListType]
+	keys, hasKeys := t.Extras[kubernetesListMapKeys]
+	if !hasListType {
+		if hasKeys {
+			v.violation(ptr, "%s requires %s map", kubernetesListMapKeys, kubernetesListType)
+		}
+		return
+	}
+	if t.Type != "array" {
+		v.violation(ptr, "%s requires type array", kubernetesListType)
+	}
+	switch listType {
+	case "atomic", "set":
+		if hasKeys {
+			v.violation(ptr, "%s requires %s map", kubernetesListMapKeys, kubernetesListType)
+		}
+	case "map":
+		names := extraStrings(keys)
+		if len(names) == 0 {
+			v.violation(ptr, "%s map requires %s", kubernetesListType, kubernetesListMapKeys)
+			return
+		}
+		if t.Items == nil || t.Items.Type != "object" {
+			v.violation(ptr, "%s map requires items of type object", kubernetesListType)
+			return
+		}
+		properties := propertyMap(t.Items)
+		required := stringSet(t.Items.Required)
+		for _, name := range names {
+			if p, ok := properties[name]; !ok {
+				v.violation(ptr, "map key %q is not a property of the items", name)
+			} else if !required[name] && (p == nil || p.Default == nil) {
+				v.violation(ptr, "map key %q must be required or have a default", name)
+			}
+		}
+	default:
+		v.violation(ptr, "%s must be atomic, set or map, not %v", kubernetesListType, listType)
+	}
+}
+
+// extraStrings returns the strings of an extra keyword, given either by a
+// tag or as decoded JSON.
+func extraStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		var ss []string
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				ss = append(ss, s)
+			}
+		}
+		return ss
+	}
+	return nil
+}
+
+type junctor struct {
+	ptr string
+	t   *Type
+}
+
+// junctors returns the schemas in the allOf, anyOf, oneOf and not of t.
+func junctors(ptr string, t *Type) []junctor {
+	var js []junctor
+	for keyword, ts := range map[string][]*Type{"/allOf/": t.AllOf, "/anyOf/": t.AnyOf, "/oneOf/": t.OneOf} {
+		for i, s := range ts {
+			js = append(js, junctor{fmt.Sprintf("%s%s%d", ptr, keyword, i), s})
+		}
+	}
+	sort.Slice(js, func(i, j int) bool { return js[i].ptr < js[j].ptr })
+	if t.Not != nil {
+		js = append(js, junctor{ptr + "/not", t.Not})
+	}
+	return js
+}
+
+// specifiedOutside checks that the fields and items constrained by inside,
+// a schema within a junctor, are specified by outside too.
+func (v *structuralValidator) specifiedOutside(ptr string, outside, inside *Type) {
+	if inside == nil {
+		return
+	}
+	outsideProperties := propertyMap(outside)
+	insideProperties := propertyMap(inside)
+	for _, name := range propertyNames(inside) {
+		p := ptr + "/properties/" + escapePointer(name)
+		if o, ok := outsideProperties[name]; !ok || o == nil {
+			v.violation(p, "field must also be specified outside of allOf, anyOf, oneOf or not")
+		} else {
+			v.specifiedOutside(p, o, insideProperties[name])
+		}
+	}
+	if inside.Items != nil {
+		if outside == nil || outside.Items == nil {
+			v.violation(ptr+"/items", "items must also be specified outside of allOf, anyOf, oneOf or not")
+		} else {
+			v.specifiedOutside(ptr+"/items", outside.Items, inside.Items)
+		}
+	}
+	for _, j := range junctors(ptr, inside) {
+		v.specifiedOutside(j.ptr, outside, j.t)
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
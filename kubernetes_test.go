@@ -0,0 +1,110 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type CRDPort struct {
+	Name     string `json:"name"`
+	Port     int    `json:"port" jsonschema:"minimum=1,maximum=65535"`
+	Protocol string `json:"protocol,omitempty" jsonschema:"enum=TCP,enum=UDP,default=TCP"`
+}
+
+type CRDSpec struct {
+	Replicas *int              `json:"replicas,omitempty" jsonschema:"nullable,minimum=1"`
+	Ports    []CRDPort         `json:"ports" jsonschema:"listType=map,listMapKeys=name;protocol"`
+	Hosts    []string          `json:"hosts,omitempty" jsonschema:"uniqueItems=true"`
+	Config   json.RawMessage   `json:"config,omitempty"`
+	Extra    interface{}       `json:"extra,omitempty" jsonschema:"description=Passed on as is"`
+	Target   string            `json:"target" jsonschema:"oneof_type=string;integer"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Data     []byte            `json:"data,omitempty"`
+	Primary  *CRDPort          `json:"primary,omitempty" jsonschema:"description=The port to probe"`
+}
+
+func TestStructural(t *testing.T) {
+	r := &Reflector{}
+	s, err := r.ReflectStructural(&CRDSpec{})
+	require.NoError(t, err)
+	actual, err := json.MarshalIndent(s, "", "  ")
+	require.NoError(t, err)
+	expected, err := ioutil.ReadFile("fixtures/kubernetes.json")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(actual))
+}
+
+func TestStructuralRecursive(t *testing.T) {
+	_, err := Structural(Reflect(&TreeNode{}))
+	require.EqualError(t, err, `jsonschema: #/properties/children/items: recursive definition "TreeNode" cannot be inlined into a structural schema`)
+}
+
+func TestStructuralNotStructural(t *testing.T) {
+	s := &Schema{Type: &Type{
+		Type:       "object",
+		Properties: props("value", &Type{OneOf: []*Type{{Type: "string"}, {Type: "boolean"}}}),
+	}}
+	_, err := Structural(s)
+	require.EqualError(t, err, "jsonschema: schema is not structural: "+
+		"#/properties/value: type must be specified; "+
+		"#/properties/value/oneOf/0: type must not be specified within allOf, anyOf, oneOf or not; "+
+		"#/properties/value/oneOf/1: type must not be specified within allOf, anyOf, oneOf or not")
+}
+
+func TestValidateStructural(t *testing.T) {
+	require.NoError(t, ValidateStructural(&Type{
+		Type:       "object",
+		Properties: props("a", &Type{Type: "string"}, "b", &Type{Type: "string"}),
+		OneOf:      []*Type{{Required: []string{"a"}}, {Required: []string{"b"}}},
+	}))
+
+	err := ValidateStructural(&Type{
+		Type:                 "object",
+		Properties:           props("a", &Type{Type: "string"}),
+		AdditionalProperties: []byte(`{"type":"string"}`),
+		AnyOf: []*Type{
+			{Properties: props("a", &Type{Pattern: "^x"}, "c", &Type{MinLength: 1})},
+			{Description: "nope"},
+		},
+	})
+	require.IsType(t, &StructuralError{}, err)
+	require.Equal(t, []string{
+		"#: additionalProperties and properties are mutually exclusive",
+		"#/anyOf/0/properties/c: field must also be specified outside of allOf, anyOf, oneOf or not",
+		"#/anyOf/1: description must not be specified within allOf, anyOf, oneOf or not",
+	}, err.(*StructuralError).Violations)
+
+	err = ValidateStructural(&Type{
+		Type: "object",
+		Properties: props(
+			"ports", &Type{Type: "array", Items: &Type{Type: "object", Properties: props("name", &Type{Type: "string"})},
+				Extras: map[string]interface{}{kubernetesListType: "map", kubernetesListMapKeys: []interface{}{"name", "port"}}},
+			"tags", &Type{Type: "array", Items: &Type{Type: "string"}, UniqueItems: true,
+				Extras: map[string]interface{}{kubernetesListType: "bag"}},
+			"ref", &Type{Ref: "#/definitions/Other"},
+		),
+		AdditionalProperties: []byte("false"),
+	})
+	require.IsType(t, &StructuralError{}, err)
+	require.Equal(t, []string{
+		"#: additionalProperties must not be false",
+		`#/properties/ports: map key "name" must be required or have a default`,
+		`#/properties/ports: map key "port" is not a property of the items`,
+		"#/properties/tags: uniqueItems is not supported",
+		"#/properties/tags: x-kubernetes-list-type must be atomic, set or map, not bag",
+		"#/properties/ref: $ref is not supported",
+		"#/properties/ref: type must be specified",
+	}, err.(*StructuralError).Violations)
+}
+
+func TestListTypeTags(t *testing.T) {
+	s := Reflect(&CRDSpec{})
+	ports, _ := s.Definitions["CRDSpec"].Properties.Get("ports")
+	require.Equal(t, map[string]interface{}{
+		kubernetesListType:    "map",
+		kubernetesListMapKeys: []string{"name", "protocol"},
+	}, ports.(*Type).Extras)
+}
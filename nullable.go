@@ -0,0 +1,71 @@
+package jsonschema
+
+import (
+	"reflect"
+)
+
+// A NullableStyle is a way for the schemas of properties to allow null.
+//
+// The style isn't derived from the "$schema" of reflected schemas, which is
+// always draft-04: NullableOneOf and NullableTypeArray are both valid in
+// every draft, and NullableOpenAPI in none, so which to use depends on what
+// consumes the schemas, such as OpenAPI 3.0 or code generators that only
+// understand one of them, rather than on the draft.
+type NullableStyle int
+
+const (
+	// NullableOneOf allows null as an alternative to the schema of a
+	// property, as in {"oneOf": [{"type": "string"}, {"type": "null"}]}.
+	NullableOneOf NullableStyle = iota
+
+	// NullableTypeArray adds null to the type of the schema of a property,
+	// as in {"type": ["string", "null"]}, by adding it to AdditionalTypes.
+	// Schemas without a type, such as references, allow null with oneOf
+	// instead.
+	NullableTypeArray
+
+	// NullableOpenAPI marks the schema of a property as nullable, as in
+	// {"type": "string", "nullable": true}, for OpenAPI 3.0 documents.
+	// References are wrapped in allOf, as keywords beside them are ignored.
+	NullableOpenAPI
+)
+
+// nilable returns whether values of the type t are marshalled as null when
+// nil.
+func nilable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// nullableSchema returns a schema allowing null as well as the values t
+// allows, in the NullableStyle of r. t itself is left unchanged, as
+// definitions inlined with DoNotReference are shared.
+func (r *Reflector) nullableSchema(t *Type) *Type {
+	switch r.NullableStyle {
+	case NullableTypeArray:
+		if t.Type != "" && t.Ref == "" {
+			if t.allowsType("null") {
+				return t
+			}
+			n := *t
+			n.AdditionalTypes = append(t.AdditionalTypes[:len(t.AdditionalTypes):len(t.AdditionalTypes)], "null")
+			return &n
+		}
+	case NullableOpenAPI:
+		if t.Ref != "" {
+			return &Type{AllOf: []*Type{t}, Extras: map[string]interface{}{"nullable": true}}
+		}
+		return withExtra(t, "nullable", true)
+	}
+	return &Type{
+		OneOf: []*Type{
+			t,
+			{
+				Type: "null",
+			},
+		},
+	}
+}
@@ -0,0 +1,121 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type NilFields struct {
+	Ptr      *string          `json:"ptr"`
+	Slice    []int            `json:"slice"`
+	Map      map[string]int   `json:"map"`
+	Ref      *GrandfatherType `json:"ref"`
+	Omitted  *string          `json:"omitted,omitempty"`
+	Value    string           `json:"value"`
+	Forced   string           `json:"forced" jsonschema:"nullable"`
+	Disabled *int             `json:"disabled" jsonschema:"nullable=false"`
+}
+
+func TestNullableNilFields(t *testing.T) {
+	r := &Reflector{NullableNilFields: true}
+	properties := propertyMap(r.Reflect(&NilFields{}).Definitions["NilFields"])
+
+	// Exactly the nil fields marshalled as null are nullable, unless tagged
+	// otherwise.
+	_, values := jsonKeys(t, NilFields{})
+	for name, p := range properties {
+		value, present := values[name]
+		nullable := len(p.OneOf) == 2 && p.OneOf[1].Type == "null"
+		expected := (present && value == nil && name != "disabled") || name == "forced"
+		require.Equal(t, expected, nullable, name)
+	}
+	require.Equal(t, "#/definitions/GrandfatherType", properties["ref"].OneOf[0].Ref)
+
+	// Fields are only nullable if tagged by default.
+	properties = propertyMap(Reflect(&NilFields{}).Definitions["NilFields"])
+	require.Equal(t, "string", properties["ptr"].Type)
+	require.Len(t, properties["forced"].OneOf, 2)
+}
+
+func TestNullableStyles(t *testing.T) {
+	tests := []struct {
+		style    NullableStyle
+		expected map[string]string
+	}{
+		{NullableTypeArray, map[string]string{
+			"ptr":    `{"type":["string","null"]}`,
+			"slice":  `{"items":{"type":"integer"},"type":["array","null"]}`,
+			"ref":    `{"oneOf":[{"$schema":"http://json-schema.org/draft-04/schema#","$ref":"#/definitions/GrandfatherType"},{"type":"null"}]}`,
+			"forced": `{"type":["string","null"]}`,
+			"value":  `{"type":"string"}`,
+		}},
+		{NullableOpenAPI, map[string]string{
+			"ptr":    `{"type":"string","nullable":true}`,
+			"slice":  `{"items":{"type":"integer"},"type":"array","nullable":true}`,
+			"ref":    `{"allOf":[{"$schema":"http://json-schema.org/draft-04/schema#","$ref":"#/definitions/GrandfatherType"}],"nullable":true}`,
+			"forced": `{"type":"string","nullable":true}`,
+			"value":  `{"type":"string"}`,
+		}},
+	}
+	for _, test := range tests {
+		r := &Reflector{NullableNilFields: true, NullableStyle: test.style}
+		s := r.Reflect(&NilFields{})
+		properties := propertyMap(s.Definitions["NilFields"])
+		for name, expected := range test.expected {
+			b, err := json.Marshal(properties[name])
+			require.NoError(t, err)
+			require.JSONEq(t, expected, string(b), name)
+		}
+
+		// Schemas load as they were reflected.
+		b, err := json.Marshal(s)
+		require.NoError(t, err)
+		loaded := &Schema{}
+		require.NoError(t, json.Unmarshal(b, loaded))
+		reloaded, err := json.Marshal(loaded)
+		require.NoError(t, err)
+		require.JSONEq(t, string(b), string(reloaded))
+	}
+
+	// Definitions inlined with DoNotReference aren't made nullable with the
+	// properties that refer to them.
+	r := &Reflector{NullableNilFields: true, NullableStyle: NullableOpenAPI, DoNotReference: true}
+	s := r.Reflect(&NilFields{})
+	require.Nil(t, s.Definitions["GrandfatherType"].Extras["nullable"])
+}
+
+func TestNullableTypeArrayConsumers(t *testing.T) {
+	r := &Reflector{NullableNilFields: true, NullableStyle: NullableTypeArray}
+	s := r.Reflect(&NilFields{})
+	def := s.Definitions["NilFields"]
+	ptr := propertyMap(def)["ptr"]
+	require.Equal(t, "string", ptr.Type)
+	require.Equal(t, []string{"null"}, ptr.AdditionalTypes)
+
+	b := &bytes.Buffer{}
+	require.NoError(t, WriteTypeScript(b, s))
+	require.Contains(t, b.String(), "  ptr: string | null;\n")
+	require.Contains(t, b.String(), "  slice: number[] | null;\n")
+	require.Contains(t, b.String(), "  disabled: number;\n")
+
+	b.Reset()
+	require.NoError(t, WriteMarkdown(b, s))
+	require.Contains(t, b.String(), "| `ptr` | string or null |")
+
+	structural, err := (&Reflector{NullableNilFields: true, NullableStyle: NullableTypeArray}).ReflectStructural(&NilFields{})
+	require.NoError(t, err)
+	p, _ := structural.Properties.Get("ptr")
+	require.Equal(t, "string", p.(*Type).Type)
+	require.Empty(t, p.(*Type).AdditionalTypes)
+	require.Equal(t, true, p.(*Type).Extras["nullable"])
+
+	// Allowing null loosens a schema, and integers are numbers.
+	before := &Schema{Type: &Type{Type: "integer"}}
+	after := &Schema{Type: &Type{Type: "number", AdditionalTypes: []string{"null"}}}
+	require.Equal(t, BackwardCompatible, Summarize(Diff(before, after)))
+	require.Equal(t, ForwardCompatible, Summarize(Diff(after, before)))
+	require.Empty(t, Diff(after, &Schema{Type: &Type{Type: "null", AdditionalTypes: []string{"number"}}}))
+}
@@ -0,0 +1,387 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/iancoleman/orderedmap"
+)
+
+// A RandomGenerator generates random instances of a schema, either valid or
+// deliberately invalid, for use in property-based and fuzz tests. Instances
+// are generated as described for ExampleGenerator, except that choices are
+// made at random. The same seed always produces the same sequence of
+// instances.
+//
+// A RandomGenerator is not safe for concurrent use.
+type RandomGenerator struct {
+	schema *Schema
+	g      *instanceGenerator
+}
+
+// NewRandomGenerator creates a RandomGenerator for s, seeded with seed.
+func NewRandomGenerator(s *Schema, seed int64) *RandomGenerator {
+	return &RandomGenerator{
+		schema: s,
+		g: &instanceGenerator{
+			definitions: s.Definitions,
+			rand:        rand.New(rand.NewSource(seed)),
+			expanding:   map[string]bool{},
+		},
+	}
+}
+
+// Valid returns a random instance of the schema.
+func (r *RandomGenerator) Valid() interface{} {
+	return r.g.generate(r.schema.Type)
+}
+
+// ValidJSON returns a random instance of the schema as JSON.
+func (r *RandomGenerator) ValidJSON() ([]byte, error) {
+	return json.Marshal(r.Valid())
+}
+
+// Decode decodes a random instance of the schema into v, which should be a
+// pointer to the Go type the schema was reflected from.
+func (r *RandomGenerator) Decode(v interface{}) error {
+	b, err := r.ValidJSON()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// A Violation describes the constraint broken by an invalid instance.
+type Violation struct {
+	// Path is the JSON pointer of the invalid value within the instance.
+	Path string
+	// Keyword is the schema keyword that the value violates.
+	Keyword string
+}
+
+func (v *Violation) String() string {
+	return fmt.Sprintf("#%s: %s", v.Path, v.Keyword)
+}
+
+// Invalid returns a random instance of the schema that violates one of the
+// given keywords, or any keyword that can be violated if none are given,
+// along with a description of the violation. Instances are made invalid by
+// changing a single value of a valid instance, which may happen to violate
+// other keywords too. If no instance violating the keywords could be found,
+// the returned Violation is nil.
+//
+// The keywords that can be violated are "type", "enum", "minimum",
+// "maximum", "multipleOf", "minLength", "maxLength", "pattern", "format",
+// "required", "additionalProperties", "minItems", "maxItems" and
+// "uniqueItems".
+func (r *RandomGenerator) Invalid(keywords ...string) (interface{}, *Violation) {
+	wanted := stringSet(keywords)
+	for attempt := 0; attempt < 16; attempt++ {
+		v := r.Valid()
+		m := &mutator{g: r.g, wanted: wanted}
+		m.collect("", r.schema.Type, v, func(c interface{}) { v = c })
+		if len(m.mutations) == 0 {
+			continue
+		}
+		mutation := m.mutations[r.g.intn(len(m.mutations))]
+		mutation.apply()
+		return v, &mutation.Violation
+	}
+	return nil, nil
+}
+
+// InvalidJSON returns a random invalid instance of the schema as JSON. See
+// Invalid.
+func (r *RandomGenerator) InvalidJSON(keywords ...string) ([]byte, *Violation, error) {
+	v, violation := r.Invalid(keywords...)
+	if violation == nil {
+		return nil, nil, nil
+	}
+	b, err := json.Marshal(v)
+	return b, violation, err
+}
+
+// A Corpus collects seed inputs for a fuzz test. It is implemented by
+// *testing.F.
+type Corpus interface {
+	Add(args ...interface{})
+}
+
+// AddSeeds adds n random valid instances of the schema to f, as JSON
+// encoded []byte arguments.
+func (r *RandomGenerator) AddSeeds(f Corpus, n int) error {
+	for i := 0; i < n; i++ {
+		b, err := r.ValidJSON()
+		if err != nil {
+			return err
+		}
+		f.Add(b)
+	}
+	return nil
+}
+
+// AddInvalidSeeds adds n random invalid instances of the schema to f, as JSON
+// encoded []byte arguments. See Invalid for the meaning of keywords.
+func (r *RandomGenerator) AddInvalidSeeds(f Corpus, n int, keywords ...string) error {
+	for i := 0; i < n; i++ {
+		b, violation, err := r.InvalidJSON(keywords...)
+		if err != nil {
+			return err
+		}
+		if violation == nil {
+			return fmt.Errorf("jsonschema: no instance violating %s could be generated", strings.Join(keywords, ", "))
+		}
+		f.Add(b)
+	}
+	return nil
+}
+
+// invalidFormats holds a value that is not in each string format.
+var invalidFormats = map[string]string{
+	"date-time": "not-a-date-time",
+	"date":      "2006-13-45",
+	"time":      "25:61:00",
+	"email":     "not-an-email",
+	"hostname":  "not_a_hostname!",
+	"ipv4":      "256.0.0.1",
+	"ipv6":      "2001:db8:::1",
+	"uri":       "not a uri",
+	"uuid":      "not-a-uuid",
+	"regex":     "(",
+}
+
+type mutation struct {
+	Violation
+	apply func()
+}
+
+// mutator collects the ways in which a valid instance can be made invalid.
+type mutator struct {
+	g         *instanceGenerator
+	wanted    map[string]bool
+	mutations []mutation
+}
+
+func (m *mutator) add(path, keyword string, apply func()) {
+	if len(m.wanted) == 0 || m.wanted[keyword] {
+		m.mutations = append(m.mutations, mutation{Violation{Path: path, Keyword: keyword}, apply})
+	}
+}
+
+// schemaOf returns the schema that v, an instance of t, was generated from,
+// or nil if it cannot be told apart from other alternatives.
+func (m *mutator) schemaOf(t *Type, v interface{}) *Type {
+	t = m.g.resolve(t)
+	if t == nil || v == nil {
+		return nil
+	}
+	var alternatives []*Type
+	for _, s := range append(t.AnyOf[:len(t.AnyOf):len(t.AnyOf)], t.OneOf...) {
+		if s = m.g.resolve(s); s != nil && s.Type != "null" {
+			alternatives = append(alternatives, s)
+		}
+	}
+	if len(alternatives) > 1 {
+		return nil
+	}
+	u := *t
+	u.AnyOf, u.OneOf = nil, nil
+	u.AllOf = append(t.AllOf[:len(t.AllOf):len(t.AllOf)], alternatives...)
+	return m.g.combine(&u)
+}
+
+// collect adds the mutations of v, an instance of t at path, and of the
+// values within it. Mutations replace v by calling set.
+func (m *mutator) collect(path string, t *Type, v interface{}, set func(interface{})) {
+	if t = m.schemaOf(t, v); t == nil {
+		return
+	}
+	if t.Type != "" {
+		for _, c := range []struct {
+			typ   string
+			value interface{}
+		}{{"string", "string"}, {"integer", 1}, {"boolean", true}} {
+			if !t.allowsType(c.typ) {
+				value := c.value
+				m.add(path, "type", func() { set(value) })
+				break
+			}
+		}
+	}
+	if len(t.Enum) > 0 {
+		if c, ok := m.notInEnum(t); ok {
+			m.add(path, "enum", func() { set(c) })
+		}
+	}
+
+	switch v := v.(type) {
+	case *orderedmap.OrderedMap:
+		m.collectObject(path, t, v)
+	case []interface{}:
+		m.collectArray(path, t, v, set)
+	case string:
+		m.collectString(path, t, v, set)
+	case bool:
+	default:
+		if f, ok := toFloat(v); ok {
+			m.collectNumber(path, t, f, set)
+		}
+	}
+}
+
+func (m *mutator) notInEnum(t *Type) (interface{}, bool) {
+	enum := valueSet(t.Enum)
+	for _, c := range []interface{}{true, false} {
+		if t.Type == "boolean" && !enum[diffValue(c)] {
+			return c, true
+		}
+	}
+	for i := 0; i < 16; i++ {
+		c := m.g.generate(&Type{Type: t.Type})
+		if t.Type == "" {
+			c = m.g.randomString(8)
+		}
+		if c != nil && !enum[diffValue(c)] {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+func (m *mutator) collectObject(path string, t *Type, o *orderedmap.OrderedMap) {
+	for _, name := range t.Required {
+		name := name
+		if _, ok := o.Get(name); ok {
+			m.add(path, "required", func() { o.Delete(name) })
+		}
+	}
+	if !allowsAdditionalProperties(t) {
+		key := "unexpected"
+		for i := 2; m.declared(t, key); i++ {
+			key = fmt.Sprintf("unexpected%d", i)
+		}
+		m.add(path, "additionalProperties", func() { o.Set(key, "unexpected") })
+	}
+
+	properties := propertyMap(t)
+	for _, key := range o.Keys() {
+		key := key
+		c, _ := o.Get(key)
+		p, ok := properties[key]
+		if !ok {
+			p = m.patternProperty(t, key)
+		}
+		if p == nil {
+			p = additionalPropertiesSchema(t)
+		}
+		m.collect(path+"/"+escapePointer(key), p, c, func(c interface{}) { o.Set(key, c) })
+	}
+}
+
+// declared reports whether key is described by the properties or
+// patternProperties of t.
+func (m *mutator) declared(t *Type, key string) bool {
+	if _, ok := propertyMap(t)[key]; ok {
+		return true
+	}
+	return m.patternProperty(t, key) != nil
+}
+
+func (m *mutator) patternProperty(t *Type, key string) *Type {
+	for _, pattern := range sortedDefinitionNames(t.PatternProperties) {
+		if re, err := regexp.Compile(pattern); err == nil && re.MatchString(key) {
+			return t.PatternProperties[pattern]
+		}
+	}
+	return nil
+}
+
+func (m *mutator) collectArray(path string, t *Type, items []interface{}, set func(interface{})) {
+	if t.MinItems > 0 && len(items) >= t.MinItems {
+		m.add(path, "minItems", func() { set(items[:t.MinItems-1]) })
+	}
+	if t.MaxItems > 0 {
+		m.add(path, "maxItems", func() {
+			more := append([]interface{}(nil), items...)
+			for len(more) <= t.MaxItems {
+				more = append(more, m.g.generate(t.Items))
+			}
+			set(more)
+		})
+	}
+	if t.UniqueItems && len(items) > 0 {
+		m.add(path, "uniqueItems", func() { set(append(items[:len(items):len(items)], items[0])) })
+	}
+	for i := range items {
+		i := i
+		m.collect(fmt.Sprintf("%s/%d", path, i), t.Items, items[i], func(c interface{}) { items[i] = c })
+	}
+}
+
+func (m *mutator) collectString(path string, t *Type, s string, set func(interface{})) {
+	if n := utf8.RuneCountInString(s); t.MinLength > 0 && n >= t.MinLength {
+		m.add(path, "minLength", func() { set(string([]rune(s)[:t.MinLength-1])) })
+	}
+	if t.MaxLength > 0 {
+		m.add(path, "maxLength", func() {
+			set(s + strings.Repeat("x", t.MaxLength+1-utf8.RuneCountInString(s)))
+		})
+	}
+	if c, ok := invalidFormats[t.Format]; ok {
+		m.add(path, "format", func() { set(c) })
+	}
+	if re, err := regexp.Compile(t.Pattern); err == nil && t.Pattern != "" {
+		for _, c := range []string{"", "!", s + "!", "!" + s, m.g.randomString(8)} {
+			if !re.MatchString(c) {
+				c := c
+				m.add(path, "pattern", func() { set(c) })
+				break
+			}
+		}
+	}
+}
+
+func (m *mutator) collectNumber(path string, t *Type, f float64, set func(interface{})) {
+	integer := t.Type == "integer"
+	number := func(f float64) interface{} {
+		if integer {
+			return int64(f)
+		}
+		return f
+	}
+	step := 0.5
+	if integer {
+		step = 1
+	}
+
+	lo, hi := numberBounds(t, integer)
+	if !math.IsInf(lo, -1) {
+		m.add(path, "minimum", func() { set(number(lo - step)) })
+	}
+	if !math.IsInf(hi, 1) {
+		m.add(path, "maximum", func() { set(number(hi + step)) })
+	}
+	if t.MultipleOf > 1 || (t.MultipleOf == 1 && !integer) {
+		m.add(path, "multipleOf", func() { set(number(f + step)) })
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,133 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type RandomPayload struct {
+	Name     string            `json:"name" jsonschema:"minLength=2,maxLength=6"`
+	Code     string            `json:"code" jsonschema:"pattern=^[A-Z]{3}-[0-9]+$"`
+	Level    int8              `json:"level" jsonschema:"minimum=1,maximum=5"`
+	Count    uint              `json:"count,omitempty" jsonschema:"multipleOf=5"`
+	Ratio    float32           `json:"ratio"`
+	Kind     string            `json:"kind" jsonschema:"enum=a,enum=b,enum=c"`
+	Created  time.Time         `json:"created"`
+	Server   net.IP            `json:"server,omitempty"`
+	Tags     []string          `json:"tags" jsonschema:"maxItems=3,uniqueItems=true"`
+	Scores   map[string]int    `json:"scores,omitempty"`
+	Names    map[int]string    `json:"names,omitempty"`
+	Flag     *bool             `json:"flag,omitempty" jsonschema:"nullable"`
+	Data     []byte            `json:"data,omitempty"`
+	Triple   [3]int            `json:"triple"`
+	Address  *ExampleAddress   `json:"address,omitempty"`
+	Children []*RandomPayload  `json:"children,omitempty"`
+	Extra    map[string]string `json:"extra,omitempty"`
+}
+
+var codePattern = regexp.MustCompile(`^[A-Z]{3}-[0-9]+$`)
+
+func TestRandomGeneratorDecode(t *testing.T) {
+	r := NewRandomGenerator(Reflect(&RandomPayload{}), 1)
+	kinds := map[string]bool{}
+	for i := 0; i < 200; i++ {
+		var p RandomPayload
+		require.NoError(t, r.Decode(&p))
+		require.True(t, len(p.Name) >= 2 && len(p.Name) <= 6, p.Name)
+		require.Regexp(t, codePattern, p.Code)
+		require.True(t, p.Level >= 1 && p.Level <= 5, p.Level)
+		require.Zero(t, p.Count%5)
+		require.Contains(t, []string{"a", "b", "c"}, p.Kind)
+		require.True(t, len(p.Tags) <= 3)
+		require.Empty(t, p.Children)
+		kinds[p.Kind] = true
+	}
+	require.Len(t, kinds, 3)
+}
+
+func TestRandomGeneratorSeed(t *testing.T) {
+	s := Reflect(&RandomPayload{})
+	a, err := NewRandomGenerator(s, 42).ValidJSON()
+	require.NoError(t, err)
+	b, err := NewRandomGenerator(s, 42).ValidJSON()
+	require.NoError(t, err)
+	c, err := NewRandomGenerator(s, 43).ValidJSON()
+	require.NoError(t, err)
+	require.Equal(t, string(a), string(b))
+	require.NotEqual(t, string(a), string(c))
+}
+
+func TestRandomGeneratorInvalid(t *testing.T) {
+	r := NewRandomGenerator(Reflect(&RandomPayload{}), 1)
+	for _, keyword := range []string{
+		"type", "enum", "minimum", "maximum", "multipleOf", "minLength", "maxLength",
+		"pattern", "format", "required", "additionalProperties", "minItems", "maxItems", "uniqueItems",
+	} {
+		t.Run(keyword, func(t *testing.T) {
+			v, violation := r.Invalid(keyword)
+			require.NotNil(t, violation)
+			require.Equal(t, keyword, violation.Keyword)
+			require.NotNil(t, v)
+		})
+	}
+
+	// Both the code and the address's zip have a pattern.
+	v, violation := r.Invalid("pattern")
+	for violation.Path != "/code" {
+		v, violation = r.Invalid("pattern")
+	}
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	var p RandomPayload
+	require.NoError(t, json.Unmarshal(b, &p))
+	require.NotRegexp(t, codePattern, p.Code)
+
+	v, violation = r.Invalid("minimum")
+	require.Equal(t, "/level", violation.Path)
+	b, err = json.Marshal(v)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &p))
+	require.Equal(t, int8(0), p.Level)
+
+	_, violation = NewRandomGenerator(Reflect(&TreeNode{}), 1).Invalid("minimum")
+	require.Nil(t, violation)
+}
+
+type recordingCorpus struct {
+	seeds [][]byte
+}
+
+func (c *recordingCorpus) Add(args ...interface{}) {
+	c.seeds = append(c.seeds, args[0].([]byte))
+}
+
+func TestRandomGeneratorSeeds(t *testing.T) {
+	r := NewRandomGenerator(Reflect(&RandomPayload{}), 1)
+	c := &recordingCorpus{}
+	require.NoError(t, r.AddSeeds(c, 3))
+	require.NoError(t, r.AddInvalidSeeds(c, 2, "required"))
+	require.Len(t, c.seeds, 5)
+	for _, seed := range c.seeds[:3] {
+		require.NoError(t, json.Unmarshal(seed, &RandomPayload{}))
+	}
+	require.Error(t, r.AddInvalidSeeds(c, 1, "no-such-keyword"))
+}
+
+func FuzzRandomPayload(f *testing.F) {
+	r := NewRandomGenerator(Reflect(&RandomPayload{}), 1)
+	require.NoError(f, r.AddSeeds(f, 10))
+	require.NoError(f, r.AddInvalidSeeds(f, 10))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var p RandomPayload
+		if err := json.Unmarshal(b, &p); err != nil {
+			return
+		}
+		_, err := json.Marshal(&p)
+		require.NoError(t, err)
+	})
+}
@@ -3,14 +3,22 @@
 // If json tags are present on struct fields, they will be used to infer
 // property names and if a property is required (omitempty is present).
 //
+// The fields of structs are resolved as encoding/json resolves them. The
+// fields of embedded structs, whether untagged or tagged without a name, are
+// promoted, and embedded types that aren't structs, such as an embedded map
+// type, are properties named after their type. Earlier versions dropped
+// such embedded types, so schemas of structs embedding them gain a property.
+//
 // [1] http://json-schema.org/latest/json-schema-validation.html
 package jsonschema
 
 import (
+	"bytes"
 	"encoding/json"
 	"net"
 	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -39,6 +47,13 @@ type customSchemaType interface {
 
 var customType = reflect.TypeOf((*customSchemaType)(nil)).Elem()
 
+// customSchemaDefaults is used to detect if the type provides a populated
+// value whose non-zero fields are the defaults of its properties. See
+// Reflector.ReflectWithDefaults.
+type customSchemaDefaults interface {
+	JSONSchemaDefaults() interface{}
+}
+
 // customSchemaGetFieldDocString
 type customSchemaGetFieldDocString interface {
 	GetFieldDocString(fieldName string) string
@@ -53,6 +68,9 @@ type Type struct {
 	// RFC draft-wright-json-schema-00
 	Version string `json:"$schema,omitempty"` // section 6.1
 	Ref     string `json:"$ref,omitempty"`    // section 7
+	// RFC draft-zyp-json-schema-04, section 7.2. Later drafts spell it
+	// "$id", which is read into ID as well.
+	ID string `json:"id,omitempty"`
 	// RFC draft-wright-json-schema-validation-00, section 5
 	MultipleOf           int                    `json:"multipleOf,omitempty"`           // section 5.1
 	Maximum              int                    `json:"maximum,omitempty"`              // section 5.2
@@ -76,6 +94,7 @@ type Type struct {
 	Dependencies         map[string]*Type       `json:"dependencies,omitempty"`         // section 5.19
 	Enum                 []interface{}          `json:"enum,omitempty"`                 // section 5.20
 	Type                 string                 `json:"type,omitempty"`                 // section 5.21
+	AdditionalTypes      []string               `json:"-"`                              // types besides Type, as in "type": ["string", "null"]
 	AllOf                []*Type                `json:"allOf,omitempty"`                // section 5.22
 	AnyOf                []*Type                `json:"anyOf,omitempty"`                // section 5.23
 	OneOf                []*Type                `json:"oneOf,omitempty"`                // section 5.24
@@ -104,11 +123,13 @@ func Reflect(v interface{}) *Schema {
 
 // ReflectFromType generates root schema using the default Reflector
 func ReflectFromType(t reflect.Type) *Schema {
-	r := &Reflector{}
-	return r.ReflectFromType(t)
+	return defaultReflector.ReflectFromType(t)
 }
 
 // A Reflector reflects values into a Schema.
+//
+// A Reflector is safe for concurrent use, as long as its options aren't
+// changed while it is in use.
 type Reflector struct {
 	// AllowAdditionalProperties will cause the Reflector to generate a schema
 	// with additionalProperties to 'true' for all struct types. This means
@@ -131,15 +152,39 @@ type Reflector struct {
 	// are present
 	PreferYAMLSchema bool
 
+	// TagKeys describe the tags that name fields, for schemas of what other
+	// decoders than encoding/json accept, e.g. []TagKey{MapstructureTagKey}.
+	// A field is named by the first of them it has, which also decides
+	// whether it is required, ignored or a catch-all, and is inlined if any
+	// of them inlines it. Untagged fields are treated as the first TagKey
+	// describes. By default fields are named by json tags, or yaml tags if
+	// they have none, as PreferYAMLSchema and YAMLEmbeddedStructs describe.
+	TagKeys []TagKey
+
+	// KeyNamer names the properties of fields whose tags don't name them,
+	// which are otherwise named after the fields themselves. The names it
+	// returns are used in required lists and oneof_required groups too. See
+	// SnakeCase, CamelCase, KebabCase and LowerCase.
+	KeyNamer func(string) string
+
 	// ExpandedStruct will cause the toplevel definitions of the schema not
 	// be referenced itself to a definition.
 	ExpandedStruct bool
 
+	// SortProperties will cause the Reflector to sort the properties of
+	// structs, and their required properties, by name rather than keeping
+	// the order in which their fields are declared. This keeps schemas
+	// stable when fields move between embedded structs. See also
+	// MarshalCanonical.
+	SortProperties bool
+
 	// Do not reference definitions.
 	// All types are still registered under the "definitions" top-level object,
 	// but instead of $ref fields in containing types, the entire definition
 	// of the contained type is inserted.
 	// This will cause the entire structure of types to be output in one tree.
+	// See InlineRefs for doing the same to an existing schema, which also
+	// handles recursive types.
 	DoNotReference bool
 
 	// Use package paths as well as type names, to avoid conflicts.
@@ -149,6 +194,26 @@ type Reflector struct {
 	// noticeable when using DoNotReference.
 	FullyQualifyTypeNames bool
 
+	// DisambiguateTypeNames will cause the Reflector to resolve conflicting
+	// type names by prefixing them with the shortest package path suffix that
+	// tells them apart, e.g. "dbConfig" and "httpConfig" for two types named
+	// Config in packages ending in "/db" and "/http". Only types whose names
+	// actually conflict are renamed, so other names remain short. Without
+	// this setting conflicts are reported as a *NameCollisionError by
+	// SchemaSet, and overwrite each other when using Reflect.
+	DisambiguateTypeNames bool
+
+	// NullableNilFields will cause the Reflector to allow null for the
+	// fields that are marshalled as null when nil, pointers, slices and
+	// maps, unless they are omitted when empty. Fields can still be made
+	// nullable or not with `jsonschema:"nullable"` and
+	// `jsonschema:"nullable=false"` tags.
+	NullableNilFields bool
+
+	// NullableStyle is the way properties allow null, oneOf alternatives by
+	// default.
+	NullableStyle NullableStyle
+
 	// IgnoredTypes defines a slice of types that should be ignored in the schema,
 	// switching to just allowing additional properties instead.
 	IgnoredTypes []interface{}
@@ -159,9 +224,32 @@ type Reflector struct {
 	// TypeNamer allows customizing of type names
 	TypeNamer func(reflect.Type) string
 
+	// BaseSchemaID is the absolute URI schemas are hosted under, such as
+	// "https://schemas.example.com/". When set, root schemas are given an
+	// "id" made of BaseSchemaID and the ID of their type.
+	BaseSchemaID string
+
+	// TypeIDer allows customizing the IDs of types, which are resolved
+	// relative to BaseSchemaID and name the documents output by
+	// SchemaSet.Documents. IDs default to the package path and name of the
+	// type, e.g. "github.com/alecthomas/jsonschema/Reflector.json".
+	TypeIDer func(reflect.Type) string
+
 	// AdditionalFields allows adding structfields for a given type
 	AdditionalFields func(reflect.Type) []reflect.StructField
 
+	// FieldMapper allows customizing the property reflected from the field f
+	// of the struct type parent, e.g. based on tags other than jsonschema.
+	// It is called with the property once its tags have been processed,
+	// before it is made nullable, and returns the property to use: prop
+	// itself, possibly modified, a replacement, or nil to drop the property.
+	FieldMapper func(parent reflect.Type, f reflect.StructField, prop *Type) *Type
+
+	// TagReaders read keywords from tags other than jsonschema tags, such as
+	// the validate tags read by ValidateTagReader. Keywords given by
+	// jsonschema tags take precedence over those read by TagReaders.
+	TagReaders []TagReader
+
 	// CommentMap is a dictionary of fully qualified go types and fields to comment
 	// strings that will be used if a description has not already been provided in
 	// the tags. Types and fields are added to the package path using "." as a
@@ -177,16 +265,70 @@ type Reflector struct {
 	//
 	// See also: AddGoComments
 	CommentMap map[string]string
+
+	// Cache enables caching the schemas of struct types, so that reflecting
+	// a type again, or another type with fields of the same types, mostly
+	// costs copies of the cached schemas. The cache holds the schemas
+	// reflected with the options the Reflector was last used with, and is
+	// cleared when they change. Telling whether they changed costs a
+	// comparison of the options on each call, apart from CommentMap, which is
+	// only compared by identity and size: assign a new map rather than
+	// changing the comments of one already used. Nothing is cached while
+	// DoNotReference, DisambiguateTypeNames or any option holding a function
+	// is set.
+	Cache bool
+
+	cache *reflectCache
 }
 
 // Reflect reflects to Schema from a value.
+//
+// Unless DisambiguateTypeNames is set, of different types reflected to the
+// same definition name only the first is defined, and references to the others
+// refer to it, and defaults that can't be parsed are left out. Use
+// ReflectStrict to detect this.
 func (r *Reflector) Reflect(v interface{}) *Schema {
 	return r.ReflectFromType(reflect.TypeOf(v))
 }
 
 // ReflectFromType generates root schema
 func (r *Reflector) ReflectFromType(t reflect.Type) *Schema {
-	definitions := Definitions{}
+	s, _ := r.reflectFromType(t)
+	return s
+}
+
+// ReflectStrict is like Reflect, but returns a *NameCollisionError if
+// different types were reflected to the same definition name and couldn't be
+// disambiguated, or an *InvalidDefaultError if the default tags of a field
+// couldn't be parsed.
+func (r *Reflector) ReflectStrict(v interface{}) (*Schema, error) {
+	return r.ReflectFromTypeStrict(reflect.TypeOf(v))
+}
+
+// ReflectFromTypeStrict is like ReflectFromType, but returns a
+// *NameCollisionError if different types were reflected to the same definition
+// name and couldn't be disambiguated, or an *InvalidDefaultError if the
+// default tags of a field couldn't be parsed.
+func (r *Reflector) ReflectFromTypeStrict(t reflect.Type) (*Schema, error) {
+	return r.reflectFromType(t)
+}
+
+func (r *Reflector) reflectFromType(t reflect.Type) (*Schema, error) {
+	var root *Type
+	definitions := r.reflectDefinitions(func(definitions *definitionSet) {
+		root = r.reflectRoot(definitions, t)
+	})
+	if r.BaseSchemaID != "" {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		root.ID = r.schemaID(r.typeID(t))
+	}
+	return &Schema{Type: root, Definitions: definitions.definitions}, definitions.err()
+}
+
+// reflectRoot reflects t as the root of a schema into definitions.
+func (r *Reflector) reflectRoot(definitions *definitionSet, t reflect.Type) *Type {
 	if r.ExpandedStruct {
 		st := &Type{
 			Version:              Version,
@@ -198,16 +340,14 @@ func (r *Reflector) ReflectFromType(t reflect.Type) *Schema {
 			st.AdditionalProperties = []byte("true")
 		}
 		r.reflectStructFields(st, definitions, t)
+		r.sortProperties(st)
 		r.reflectStruct(definitions, t)
-		delete(definitions, r.typeName(t))
-		return &Schema{Type: st, Definitions: definitions}
+		definitions.remove(t)
+		r.reflectSchemaDefaults(definitions, t, st)
+		return st
 	}
 
-	s := &Schema{
-		Type:        r.reflectTypeToSchema(definitions, t),
-		Definitions: definitions,
-	}
-	return s
+	return r.reflectTypeToSchema(definitions, t)
 }
 
 // Definitions hold schema definitions.
@@ -236,10 +376,20 @@ type protoEnum interface {
 
 var protoEnumType = reflect.TypeOf((*protoEnum)(nil)).Elem()
 
-func (r *Reflector) reflectTypeToSchema(definitions Definitions, t reflect.Type) *Type {
+func (r *Reflector) reflectTypeToSchema(definitions *definitionSet, t reflect.Type) *Type {
+	if deps := definitions.deps; deps != nil {
+		// Only the types reflected by the fields themselves are recorded, as
+		// reflecting them again reflects the types within them too.
+		*deps = append(*deps, t)
+		definitions.deps = nil
+		defer func() { definitions.deps = deps }()
+	}
+
 	// Already added to definitions?
-	if _, ok := definitions[r.typeName(t)]; ok && !r.DoNotReference {
-		return &Type{Ref: "#/definitions/" + r.typeName(t)}
+	if !r.DoNotReference {
+		if _, ok := definitions.get(t); ok {
+			return &Type{Ref: definitions.ref(t)}
+		}
 	}
 
 	if r.TypeMapper != nil {
@@ -253,8 +403,8 @@ func (r *Reflector) reflectTypeToSchema(definitions Definitions, t reflect.Type)
 	}
 
 	// jsonpb will marshal protobuf enum options as either strings or integers.
-	// It will unmarshal either.
-	if t.Implements(protoEnumType) {
+	// It will unmarshal either, as do some Kubernetes types.
+	if t.Implements(protoEnumType) || intOrStringTypes[fullyQualifiedTypeName(t)] {
 		return &Type{OneOf: []*Type{
 			{Type: "string"},
 			{Type: "integer"},
@@ -346,7 +496,7 @@ func (r *Reflector) reflectTypeToSchema(definitions Definitions, t reflect.Type)
 	panic("unsupported type " + t.String())
 }
 
-func (r *Reflector) reflectCustomType(definitions Definitions, t reflect.Type) *Type {
+func (r *Reflector) reflectCustomType(definitions *definitionSet, t reflect.Type) *Type {
 	if t.Kind() == reflect.Ptr {
 		return r.reflectCustomType(definitions, t.Elem())
 	}
@@ -355,22 +505,27 @@ func (r *Reflector) reflectCustomType(definitions Definitions, t reflect.Type) *
 		v := reflect.New(t)
 		o := v.Interface().(customSchemaType)
 		st := o.JSONSchemaType()
-		definitions[r.typeName(t)] = st
-		if r.DoNotReference {
-			return st
-		} else {
-			return &Type{
-				Version: Version,
-				Ref:     "#/definitions/" + r.typeName(t),
-			}
-		}
+		definitions.add(t, st)
+		return r.definitionRef(definitions, t, st)
 	}
 
 	return nil
 }
 
+// definitionRef returns the schema to use in place of the definition st of
+// type t, which is either a reference to it or st itself.
+func (r *Reflector) definitionRef(definitions *definitionSet, t reflect.Type, st *Type) *Type {
+	if r.DoNotReference {
+		return st
+	}
+	return &Type{
+		Version: Version,
+		Ref:     definitions.ref(t),
+	}
+}
+
 // Reflects a struct to a JSON Schema type.
-func (r *Reflector) reflectStruct(definitions Definitions, t reflect.Type) *Type {
+func (r *Reflector) reflectStruct(definitions *definitionSet, t reflect.Type) *Type {
 	if st := r.reflectCustomType(definitions, t); st != nil {
 		return st
 	}
@@ -382,17 +537,18 @@ func (r *Reflector) reflectStruct(definitions Definitions, t reflect.Type) *Type
 				Properties:           orderedmap.New(),
 				AdditionalProperties: []byte("true"),
 			}
-			definitions[r.typeName(t)] = st
-
-			if r.DoNotReference {
-				return st
-			} else {
-				return &Type{
-					Version: Version,
-					Ref:     "#/definitions/" + r.typeName(t),
-				}
-			}
+			definitions.add(t, st)
+			return r.definitionRef(definitions, t, st)
+		}
+	}
+
+	if st, deps, ok := definitions.cache.load(t); ok {
+		definitions.add(t, st)
+		for _, dep := range deps {
+			r.reflectTypeToSchema(definitions, dep)
 		}
+		r.reflectSchemaDefaults(definitions, t, st)
+		return r.definitionRef(definitions, t, st)
 	}
 
 	st := &Type{
@@ -404,20 +560,41 @@ func (r *Reflector) reflectStruct(definitions Definitions, t reflect.Type) *Type
 	if r.AllowAdditionalProperties {
 		st.AdditionalProperties = []byte("true")
 	}
-	definitions[r.typeName(t)] = st
-	r.reflectStructFields(st, definitions, t)
-
-	if r.DoNotReference {
-		return st
+	definitions.add(t, st)
+	if definitions.cache == nil {
+		r.reflectStructFields(st, definitions, t)
+		r.sortProperties(st)
 	} else {
-		return &Type{
-			Version: Version,
-			Ref:     "#/definitions/" + r.typeName(t),
+		var deps []reflect.Type
+		outer := definitions.deps
+		definitions.deps = &deps
+		invalid := len(definitions.invalidDefaults)
+		r.reflectStructFields(st, definitions, t)
+		definitions.deps = outer
+		r.sortProperties(st)
+		// Schemas with invalid defaults aren't cached, so that reflecting
+		// them again reports the defaults again.
+		if len(definitions.invalidDefaults) == invalid {
+			definitions.cache.store(t, st, deps)
 		}
 	}
+	r.reflectSchemaDefaults(definitions, t, st)
+
+	return r.definitionRef(definitions, t, st)
 }
 
-func (r *Reflector) reflectStructFields(st *Type, definitions Definitions, t reflect.Type) {
+// reflectSchemaDefaults sets the defaults of st, the schema of the struct
+// type t, to those given by its JSONSchemaDefaults method if it has one.
+func (r *Reflector) reflectSchemaDefaults(definitions *definitionSet, t reflect.Type, st *Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if o, ok := reflect.New(t).Interface().(customSchemaDefaults); ok {
+		r.defaultsFromValue(&Schema{Type: st, Definitions: definitions.definitions}, st, o.JSONSchemaDefaults())
+	}
+}
+
+func (r *Reflector) reflectStructFields(st *Type, definitions *definitionSet, t reflect.Type) {
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
@@ -425,61 +602,70 @@ func (r *Reflector) reflectStructFields(st *Type, definitions Definitions, t ref
 		return
 	}
 
-	var getFieldDocString customGetFieldDocString
-	if t.Implements(customStructGetFieldDocString) {
-		v := reflect.New(t)
-		o := v.Interface().(customSchemaGetFieldDocString)
-		getFieldDocString = o.GetFieldDocString
-	}
+	getFieldDocStrings := map[reflect.Type]customGetFieldDocString{}
+	for _, f := range r.structFields(t) {
+		if f.remain {
+			r.reflectRemain(st, definitions, f.StructField)
+			continue
+		}
 
-	handleField := func(f reflect.StructField) {
-		name, shouldEmbed, required, nullable := r.reflectFieldName(f)
-		// if anonymous and exported type should be processed recursively
-		// current type should inherit properties of anonymous one
-		if name == "" {
-			if shouldEmbed {
-				r.reflectStructFields(st, definitions, f.Type)
-			}
-			return
+		getFieldDocString, ok := getFieldDocStrings[f.parent]
+		if !ok && f.parent.Implements(customStructGetFieldDocString) {
+			v := reflect.New(f.parent)
+			o := v.Interface().(customSchemaGetFieldDocString)
+			getFieldDocString = o.GetFieldDocString
+			getFieldDocStrings[f.parent] = getFieldDocString
 		}
 
-		property := r.reflectTypeToSchema(definitions, f.Type)
-		property.structKeywordsFromTags(f, st, name)
+		name, required := f.name, f.required
+		readTags := r.readTags(f.StructField)
+		if propertyTags, _ := splitDive(readTags); containsTag(propertyTags, "required") {
+			required = true
+		}
+
+		var property *Type
+		if f.quoted {
+			property = quotedSchema(f.Type)
+		} else {
+			property = r.reflectTypeToSchema(definitions, f.Type)
+		}
+		if err := property.structKeywordsFromTags(f.StructField, st, name, readTags); err != nil {
+			definitions.invalidDefault(f.parent, err.(*InvalidDefaultError))
+		}
+		if f.quoted && property.Default != nil {
+			property.Default = quoteValue(property.Default)
+		}
 		if property.Description == "" {
-			property.Description = r.lookupComment(t, f.Name)
+			property.Description = r.lookupComment(f.parent, f.Name)
 		}
 		if getFieldDocString != nil {
 			property.Description = getFieldDocString(f.Name)
 		}
-
-		if nullable {
-			property = &Type{
-				OneOf: []*Type{
-					property,
-					{
-						Type: "null",
-					},
-				},
+		if r.FieldMapper != nil {
+			if property = r.FieldMapper(f.parent, f.StructField, property); property == nil {
+				continue
 			}
 		}
 
+		if f.nullable {
+			property = r.nullableSchema(property)
+		}
+
 		st.Properties.Set(name, property)
 		if required {
 			st.Required = append(st.Required, name)
 		}
 	}
+}
 
-	for i := 0; i < t.NumField(); i++ {
-		f := t.Field(i)
-		handleField(f)
-	}
-	if r.AdditionalFields != nil {
-		if af := r.AdditionalFields(t); af != nil {
-			for _, sf := range af {
-				handleField(sf)
-			}
-		}
+// sortProperties sorts the properties of st by name if SortProperties is
+// set.
+func (r *Reflector) sortProperties(st *Type) {
+	if !r.SortProperties {
+		return
 	}
+	st.Properties.SortKeys(sort.Strings)
+	sort.Strings(st.Required)
 }
 
 func (r *Reflector) lookupComment(t reflect.Type, name string) string {
@@ -495,10 +681,34 @@ func (r *Reflector) lookupComment(t reflect.Type, name string) string {
 	return r.CommentMap[n]
 }
 
-func (t *Type) structKeywordsFromTags(f reflect.StructField, parentType *Type, propertyName string) {
+// structKeywordsFromTags sets the keywords given by the tags of f, along with
+// those read from them by TagReaders, which the jsonschema tags override. It
+// returns an *InvalidDefaultError if the default of f can't be parsed.
+func (t *Type) structKeywordsFromTags(f reflect.StructField, parentType *Type, propertyName string, readTags []string) error {
 	t.Description = f.Tag.Get("jsonschema_description")
-	tags := strings.Split(f.Tag.Get("jsonschema"), ",")
+	jsonSchemaTags := splitTag(f.Tag.Get("jsonschema"))
+	readTags, itemTags := splitDive(readTags)
+	if hasKeyword(jsonSchemaTags, "enum") {
+		// Enums given by jsonschema tags replace those read by TagReaders
+		// rather than adding to them.
+		readTags = withoutKeyword(readTags, "enum")
+		itemTags = withoutKeyword(itemTags, "enum")
+	}
+	tags := append(readTags[:len(readTags):len(readTags)], jsonSchemaTags...)
 	t.genericKeywords(tags, parentType, propertyName)
+	t.typeKeywords(tags)
+	if len(itemTags) > 0 && t.Items != nil && t.Items.Ref == "" {
+		t.Items.genericKeywords(itemTags, t, "")
+		t.Items.typeKeywords(itemTags)
+	}
+	extras := strings.Split(f.Tag.Get("jsonschema_extras"), ",")
+	t.extraKeywords(extras)
+	return t.defaultKeyword(tags, f)
+}
+
+// typeKeywords sets the keywords given by tags that are specific to the type
+// of t.
+func (t *Type) typeKeywords(tags []string) {
 	switch t.Type {
 	case "string":
 		t.stringKeywords(tags)
@@ -509,8 +719,6 @@ func (t *Type) structKeywordsFromTags(f reflect.StructField, parentType *Type, p
 	case "array":
 		t.arrayKeywords(tags)
 	}
-	extras := strings.Split(f.Tag.Get("jsonschema_extras"), ",")
-	t.extraKeywords(extras)
 }
 
 // read struct tags for generic keyworks
@@ -585,7 +793,7 @@ func (t *Type) stringKeywords(tags []string) {
 				t.Pattern = val
 			case "format":
 				switch val {
-				case "date-time", "email", "hostname", "ipv4", "ipv6", "uri":
+				case "date-time", "email", "hostname", "ipv4", "ipv6", "uri", "uuid":
 					t.Format = val
 					break
 				}
@@ -595,8 +803,6 @@ func (t *Type) stringKeywords(tags []string) {
 			case "writeOnly":
 				i, _ := strconv.ParseBool(val)
 				t.WriteOnly = i
-			case "default":
-				t.Default = val
 			case "example":
 				t.Examples = append(t.Examples, val)
 			}
@@ -626,9 +832,6 @@ func (t *Type) numbericKeywords(tags []string) {
 			case "exclusiveMinimum":
 				b, _ := strconv.ParseBool(val)
 				t.ExclusiveMinimum = b
-			case "default":
-				i, _ := strconv.Atoi(val)
-				t.Default = i
 			case "example":
 				if i, err := strconv.Atoi(val); err == nil {
 					t.Examples = append(t.Examples, i)
@@ -656,7 +859,6 @@ func (t *Type) numbericKeywords(tags []string) {
 
 // read struct tags for array type keyworks
 func (t *Type) arrayKeywords(tags []string) {
-	var defaultValues []interface{}
 	for _, tag := range tags {
 		nameValue := strings.Split(tag, "=")
 		if len(nameValue) == 2 {
@@ -670,8 +872,13 @@ func (t *Type) arrayKeywords(tags []string) {
 				t.MaxItems = i
 			case "uniqueItems":
 				t.UniqueItems = true
-			case "default":
-				defaultValues = append(defaultValues, val)
+			case "listType":
+				t.setExtra(kubernetesListType, val)
+			case "listMapKeys":
+				if t.Extras == nil {
+					t.Extras = map[string]interface{}{}
+				}
+				t.Extras[kubernetesListMapKeys] = strings.Split(val, ";")
 			case "enum":
 				switch t.Items.Type {
 				case "string":
@@ -686,9 +893,6 @@ func (t *Type) arrayKeywords(tags []string) {
 			}
 		}
 	}
-	if len(defaultValues) > 0 {
-		t.Default = defaultValues
-	}
 }
 
 func (t *Type) extraKeywords(tags []string) {
@@ -723,19 +927,6 @@ func (t *Type) setExtra(key, val string) {
 	}
 }
 
-func requiredFromJSONTags(tags []string) bool {
-	if ignoredByJSONTags(tags) {
-		return false
-	}
-
-	for _, tag := range tags[1:] {
-		if tag == "omitempty" {
-			return false
-		}
-	}
-	return true
-}
-
 func requiredFromJSONSchemaTags(tags []string) bool {
 	if ignoredByJSONSchemaTags(tags) {
 		return false
@@ -748,91 +939,102 @@ func requiredFromJSONSchemaTags(tags []string) bool {
 	return false
 }
 
-func nullableFromJSONSchemaTags(tags []string) bool {
+// nullableFromJSONSchemaTags returns whether tags make a property nullable,
+// with "nullable" or "nullable=true", or not, with "nullable=false", and
+// whether they say either.
+func nullableFromJSONSchemaTags(tags []string) (nullable, ok bool) {
 	if ignoredByJSONSchemaTags(tags) {
-		return false
+		return false, false
 	}
 	for _, tag := range tags {
-		if tag == "nullable" {
-			return true
+		switch tag {
+		case "nullable", "nullable=true":
+			return true, true
+		case "nullable=false":
+			return false, true
 		}
 	}
-	return false
-}
-
-func inlineYAMLTags(tags []string) bool {
-	for _, tag := range tags {
-		if tag == "inline" {
-			return true
-		}
-	}
-	return false
-}
-
-func ignoredByJSONTags(tags []string) bool {
-	return tags[0] == "-"
+	return false, false
 }
 
 func ignoredByJSONSchemaTags(tags []string) bool {
 	return tags[0] == "-"
 }
 
-func (r *Reflector) reflectFieldName(f reflect.StructField) (string, bool, bool, bool) {
-	jsonTags, exist := f.Tag.Lookup("json")
-	yamlTags, yamlExist := f.Tag.Lookup("yaml")
-	if !exist || r.PreferYAMLSchema {
-		jsonTags = yamlTags
-		exist = yamlExist
+// reflectFieldName returns how f is read by the decoders described by the
+// TagKeys of r.
+func (r *Reflector) reflectFieldName(f reflect.StructField) fieldInfo {
+	keys := r.tagKeys()
+	tag := lookupFieldTag(f, keys)
+	if tag.ignored() {
+		return fieldInfo{}
 	}
-
-	jsonTagsList := strings.Split(jsonTags, ",")
-	yamlTagsList := strings.Split(yamlTags, ",")
-
-	if ignoredByJSONTags(jsonTagsList) {
-		return "", false, false, false
+	if tag.has(tag.key.Remain) {
+		return fieldInfo{remain: f.PkgPath == ""}
 	}
 
-	jsonSchemaTags := strings.Split(f.Tag.Get("jsonschema"), ",")
+	jsonSchemaTags := splitTag(f.Tag.Get("jsonschema"))
 	if ignoredByJSONSchemaTags(jsonSchemaTags) {
-		return "", false, false, false
+		return fieldInfo{}
 	}
 
-	name := f.Name
-	required := requiredFromJSONTags(jsonTagsList)
+	info := fieldInfo{
+		name:     f.Name,
+		tagged:   tag.name != "",
+		quoted:   tag.has(tag.key.Quoted) && quotable(f.Type),
+		required: !tag.optional(f.Type),
+	}
 
-	if r.RequiredFromJSONSchemaTags {
-		required = requiredFromJSONSchemaTags(jsonSchemaTags)
+	nullable, ok := nullableFromJSONSchemaTags(jsonSchemaTags)
+	if !ok && r.NullableNilFields {
+		nullable = nilable(f.Type) && !tag.optional(f.Type)
 	}
+	info.nullable = nullable
 
-	nullable := nullableFromJSONSchemaTags(jsonSchemaTags)
+	if r.RequiredFromJSONSchemaTags {
+		info.required = requiredFromJSONSchemaTags(jsonSchemaTags)
+	}
 
-	if jsonTagsList[0] != "" {
-		name = jsonTagsList[0]
+	if info.tagged {
+		info.name = tag.name
+	} else if r.KeyNamer != nil {
+		info.name = r.KeyNamer(info.name)
 	}
 
-	// field not anonymous and not export has no export name
-	if !f.Anonymous && f.PkgPath != "" {
-		name = ""
+	ft := f.Type
+	if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
 	}
 
-	embed := false
+	// unexported fields have no export name, unless they embed structs,
+	// whose exported fields are promoted
+	if f.PkgPath != "" && !(f.Anonymous && ft.Kind() == reflect.Struct) {
+		return fieldInfo{}
+	}
 
-	// field anonymous but without json tag should be inherited by current type
-	if f.Anonymous && !exist {
-		if !r.YAMLEmbeddedStructs {
-			name = ""
-			embed = true
-		} else {
-			name = strings.ToLower(name)
+	// field anonymous but without a tag naming it should be inherited by
+	// current type, as encoding/json promotes the fields of embedded structs
+	// whose tags only give options, e.g. `json:",omitempty"`
+	if f.Anonymous && !info.tagged {
+		if tag.key.InlineAnonymous && !r.YAMLEmbeddedStructs && ft.Kind() == reflect.Struct {
+			info.name = ""
+			info.embed = true
+		} else if r.YAMLEmbeddedStructs {
+			// yaml lowercases the names of embedded structs whatever the
+			// KeyNamer.
+			info.name = strings.ToLower(f.Name)
 		}
 	}
 
-	if yamlExist && inlineYAMLTags(yamlTagsList) {
-		name = ""
-		embed = true
+	if inlined(f, keys) {
+		info.name = ""
+		info.embed = true
 	}
 
-	return name, embed, required, nullable
+	if f.PkgPath != "" && !info.embed {
+		return fieldInfo{}
+	}
+	return info
 }
 
 func (s *Schema) MarshalJSON() ([]byte, error) {
@@ -859,7 +1061,18 @@ func (s *Schema) MarshalJSON() ([]byte, error) {
 
 func (t *Type) MarshalJSON() ([]byte, error) {
 	type Type_ Type
-	b, err := json.Marshal((*Type_)(t))
+	var (
+		b   []byte
+		err error
+	)
+	if len(t.AdditionalTypes) > 0 {
+		b, err = json.Marshal(struct {
+			*Type_
+			Type []string `json:"type"`
+		}{(*Type_)(t), t.types()})
+	} else {
+		b, err = json.Marshal((*Type_)(t))
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -878,6 +1091,136 @@ func (t *Type) MarshalJSON() ([]byte, error) {
 	}
 }
 
+// types returns the types t allows, Type followed by AdditionalTypes, or nil
+// if it doesn't give any.
+func (t *Type) types() []string {
+	if t.Type == "" {
+		return nil
+	}
+	return append([]string{t.Type}, t.AdditionalTypes...)
+}
+
+// allowsType returns whether t gives a type that allows values of the type
+// name, as "number" allows integers.
+func (t *Type) allowsType(name string) bool {
+	for _, typ := range t.types() {
+		if typ == name || (typ == "number" && name == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalJSON loads a schema, moving its "definitions" to the Schema.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	t := &Type{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return err
+	}
+	s.Type = t
+	s.Definitions = t.Definitions
+	t.Definitions = nil
+	return nil
+}
+
+// typeKeywords indexes the fields of Type by JSON keyword.
+var typeKeywords = func() map[string]int {
+	keywords := map[string]int{}
+	t := reflect.TypeOf(Type{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			keywords[name] = i
+		}
+	}
+	return keywords
+}()
+
+// UnmarshalJSON loads a schema such that marshalling it again produces the
+// same keywords: properties are decoded to *Type values in their original
+// order, and keywords that the Type fields can't hold, either because they
+// are unknown or because their value would be omitted, are kept in Extras.
+func (t *Type) UnmarshalJSON(data []byte) error {
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		return err
+	}
+	type Type_ Type
+	aux := struct {
+		*Type_
+		Type       json.RawMessage `json:"type,omitempty"`
+		Properties json.RawMessage `json:"properties,omitempty"`
+	}{Type_: (*Type_)(t)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(aux.Type), []byte("[")) {
+		var types []string
+		if err := json.Unmarshal(aux.Type, &types); err != nil {
+			return err
+		}
+		if len(types) > 0 {
+			t.Type, t.AdditionalTypes = types[0], types[1:]
+		}
+		if len(t.AdditionalTypes) == 0 {
+			t.AdditionalTypes = nil
+		}
+	} else if len(aux.Type) > 0 && string(aux.Type) != "null" {
+		if err := json.Unmarshal(aux.Type, &t.Type); err != nil {
+			return err
+		}
+	}
+	if len(aux.Properties) > 0 && string(aux.Properties) != "null" {
+		properties, err := unmarshalProperties(aux.Properties)
+		if err != nil {
+			return err
+		}
+		t.Properties = properties
+	}
+
+	if raw, ok := keywords["$id"]; ok && t.ID == "" {
+		if err := json.Unmarshal(raw, &t.ID); err == nil {
+			delete(keywords, "$id")
+		}
+	}
+
+	v := reflect.ValueOf(t).Elem()
+	for k, raw := range keywords {
+		if i, ok := typeKeywords[k]; ok && !v.Field(i).IsZero() {
+			continue
+		}
+		var val interface{}
+		if err := json.Unmarshal(raw, &val); err != nil {
+			return err
+		}
+		if t.Extras == nil {
+			t.Extras = map[string]interface{}{}
+		}
+		t.Extras[k] = val
+	}
+	return nil
+}
+
+func unmarshalProperties(data []byte) (*orderedmap.OrderedMap, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	properties := orderedmap.New()
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		property := &Type{}
+		if err := dec.Decode(property); err != nil {
+			return nil, err
+		}
+		properties.Set(key.(string), property)
+	}
+	return properties, nil
+}
+
 func (r *Reflector) typeName(t reflect.Type) string {
 	if r.TypeNamer != nil {
 		if name := r.TypeNamer(t); name != "" {
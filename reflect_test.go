@@ -2,6 +2,7 @@ package jsonschema
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net"
 	"net/url"
@@ -290,7 +291,10 @@ func TestSchemaGeneration(t *testing.T) {
 		{&CustomSliceOuter{}, &Reflector{}, "fixtures/custom_slice_type.json"},
 		{&CustomMapOuter{}, &Reflector{}, "fixtures/custom_map_type.json"},
 		{&CustomTypeFieldWithInterface{}, &Reflector{}, "fixtures/custom_type_with_interface.json"},
+		{&ValidatedUser{}, &Reflector{TagReaders: []TagReader{ValidateTagReader{}}}, "fixtures/validate_tags.json"},
+		{&MapstructureConfig{}, &Reflector{TagKeys: []TagKey{MapstructureTagKey}}, "fixtures/mapstructure_tags.json"},
 		{&examples.User{}, prepareCommentReflector(t), "fixtures/go_comments.json"},
+		{&examples.User{}, &Reflector{CommentMap: examples.CommentMap}, "fixtures/go_comments.json"},
 	}
 
 	for _, tt := range tests {
@@ -331,3 +335,50 @@ func TestBaselineUnmarshal(t *testing.T) {
 
 	require.Equal(t, strings.ReplaceAll(string(expectedJSON), `\/`, "/"), string(actualJSON))
 }
+
+type FieldMapped struct {
+	Name     string  `json:"name" db:"varchar(64)"`
+	Password string  `json:"password" secret:"true"`
+	Internal string  `json:"internal" jsonschema:"description=Not for clients"`
+	Nickname *string `json:"nickname" db:"varchar(16)" jsonschema:"nullable"`
+	Age      int     `json:"age"`
+}
+
+func TestFieldMapper(t *testing.T) {
+	var parents []reflect.Type
+	r := &Reflector{
+		FieldMapper: func(parent reflect.Type, f reflect.StructField, prop *Type) *Type {
+			parents = append(parents, parent)
+			var n int
+			if _, err := fmt.Sscanf(f.Tag.Get("db"), "varchar(%d)", &n); err == nil {
+				prop.MaxLength = n
+			}
+			if f.Tag.Get("secret") == "true" {
+				prop.setExtra("x-sensitive", "true")
+			}
+			switch f.Name {
+			case "Internal":
+				require.Equal(t, "Not for clients", prop.Description)
+				return nil
+			case "Age":
+				return &Type{Type: "integer", Minimum: 18}
+			}
+			return prop
+		},
+	}
+	s := r.Reflect(&FieldMapped{})
+	def := s.Definitions["FieldMapped"]
+	require.Equal(t, []string{"name", "password", "nickname", "age"}, def.Properties.Keys())
+	require.Equal(t, []string{"name", "password", "nickname", "age"}, def.Required)
+	require.Len(t, parents, 5)
+	for _, p := range parents {
+		require.Equal(t, reflect.TypeOf(FieldMapped{}), p)
+	}
+
+	properties := propertyMap(def)
+	require.Equal(t, 64, properties["name"].MaxLength)
+	require.Equal(t, map[string]interface{}{"x-sensitive": "true"}, properties["password"].Extras)
+	require.Equal(t, &Type{Type: "integer", Minimum: 18}, properties["age"])
+	// Nullable properties are wrapped after mapping.
+	require.Equal(t, 16, properties["nickname"].OneOf[0].MaxLength)
+}
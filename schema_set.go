@@ -0,0 +1,192 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// A SchemaSet reflects many root types into a single, shared set of
+// definitions. Unlike calling Reflect once per type, each type is only
+// defined once however many roots refer to it, and two different Go types
+// being reflected to the same definition name is reported as an error
+// instead of one silently replacing the other.
+//
+// The collected schemas can either be output as a single bundle, or as one
+// document per definition referencing each other by URI.
+type SchemaSet struct {
+	// AbsoluteRefs will cause Documents to refer to each other using absolute
+	// URIs, built from the Reflector's BaseSchemaID, instead of relative ones.
+	AbsoluteRefs bool
+
+	r           *Reflector
+	definitions *definitionSet
+	types       []reflect.Type
+}
+
+// NewSchemaSet creates an empty SchemaSet using the Reflector's settings.
+func (r *Reflector) NewSchemaSet() *SchemaSet {
+	return &SchemaSet{r: r, definitions: newDefinitionSet(r.typeName)}
+}
+
+// Add reflects the type of v into the set.
+func (s *SchemaSet) Add(v interface{}) error {
+	return s.AddType(reflect.TypeOf(v))
+}
+
+// AddType reflects t into the set. If the definitions of t clash with those
+// already in the set, and cannot be disambiguated, a *NameCollisionError is
+// returned and the set is left unchanged. So is the set if the default tags of
+// a field can't be parsed, and an *InvalidDefaultError is returned.
+//
+// With DisambiguateTypeNames, adding a type may rename definitions that were
+// already in the set.
+func (s *SchemaSet) AddType(t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if s.r.typeName(t) == "" {
+		return fmt.Errorf("jsonschema: cannot add unnamed type %s to a schema set", t)
+	}
+
+	// Reflecting every type again means renaming types after a collision
+	// also updates the references to them.
+	types := append(s.types[:len(s.types):len(s.types)], t)
+	definitions := s.r.reflectDefinitions(func(definitions *definitionSet) {
+		for _, t := range types {
+			if st := s.r.reflectTypeToSchema(definitions, t); st.Ref == "" {
+				definitions.add(t, st)
+			}
+		}
+	})
+	if err := definitions.err(); err != nil {
+		return err
+	}
+
+	s.definitions = definitions
+	s.types = types
+	return nil
+}
+
+// Roots returns the definition names of the types added to the set, in the
+// order they were added.
+func (s *SchemaSet) Roots() []string {
+	roots := make([]string, len(s.types))
+	for i, t := range s.types {
+		roots[i] = s.definitions.name(t)
+	}
+	return roots
+}
+
+// Bundle returns a single schema holding the definitions of every type in the
+// set. Individual types can be referenced as "#/definitions/<name>" from
+// within the bundle, or "<bundle URI>#/definitions/<name>" from other
+// documents.
+func (s *SchemaSet) Bundle() *Schema {
+	definitions := Definitions{}
+	for name, st := range s.definitions.definitions {
+		definitions[name] = st
+	}
+	return &Schema{
+		Type:        &Type{Version: Version},
+		Definitions: definitions,
+	}
+}
+
+// Documents returns one schema per definition in the set, keyed by the ID
+// of its type, which is also its location relative to BaseSchemaID. See
+// Reflector.TypeIDer for how IDs are chosen.
+//
+// Instead of "#/definitions/..." references, documents refer to each other
+// using URIs relative to their own location, or absolute URIs when
+// AbsoluteRefs is set. When the Reflector has a BaseSchemaID, every document
+// is also given an absolute "id".
+//
+// It fails if the types of two definitions have the same ID, as one document
+// would replace the other.
+func (s *SchemaSet) Documents() (map[string]*Schema, error) {
+	ids := make(map[string]string, len(s.definitions.types))
+	names := make(map[string]string, len(s.definitions.types))
+	typeNames := make([]string, 0, len(s.definitions.types))
+	for name := range s.definitions.types {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+	for _, name := range typeNames {
+		id := s.r.typeID(s.definitions.types[name])
+		if other, ok := names[id]; ok {
+			return nil, fmt.Errorf("jsonschema: definitions %q and %q have the same ID %q", other, name, id)
+		}
+		ids[name] = id
+		names[id] = name
+	}
+
+	docs := make(map[string]*Schema, len(s.definitions.definitions))
+	for name, st := range s.definitions.definitions {
+		id := ids[name]
+		root := st.mapRefs(func(ref string) string {
+			target, ok := ids[strings.TrimPrefix(ref, "#/definitions/")]
+			switch {
+			case !strings.HasPrefix(ref, "#/definitions/") || !ok:
+				return ref
+			case s.AbsoluteRefs:
+				return s.r.schemaID(target)
+			default:
+				return relativeRef(id, target)
+			}
+		})
+		root.Version = Version
+		if s.r.BaseSchemaID != "" {
+			root.ID = s.r.schemaID(id)
+		}
+		docs[id] = &Schema{Type: root}
+	}
+	return docs, nil
+}
+
+// WriteDir writes the documents returned by Documents to files under dir, at
+// paths given by their IDs. It fails if any ID is an absolute URI, as there
+// is no local path to write it to.
+func (s *SchemaSet) WriteDir(dir string) error {
+	docs, err := s.Documents()
+	if err != nil {
+		return err
+	}
+	for id, doc := range docs {
+		clean := path.Clean("/" + id)[1:]
+		if isAbsoluteURI(id) || clean != id {
+			return fmt.Errorf("jsonschema: cannot write document %q to a directory", id)
+		}
+		b, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(dir, filepath.FromSlash(id))
+		if err := os.MkdirAll(filepath.Dir(file), 0750); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(file, append(b, '\n'), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapRefs returns a deep copy of t in which every $ref has been replaced by
+// the result of calling fn with it.
+func (t *Type) mapRefs(fn func(ref string) string) *Type {
+	c, _ := Transform(t, func(_ string, _, t *Type) (*Type, error) {
+		c := *t
+		if c.Ref != "" {
+			c.Ref = fn(c.Ref)
+		}
+		return &c, nil
+	})
+	return c
+}
@@ -0,0 +1,151 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/jsonschema/examples"
+	"github.com/alecthomas/jsonschema/examples/animals"
+	"github.com/alecthomas/jsonschema/examples/nested"
+)
+
+type Household struct {
+	Owner examples.User `json:"owner"`
+	Wild  animals.Pet   `json:"wild"`
+}
+
+func TestSchemaSetSharesDefinitions(t *testing.T) {
+	set := (&Reflector{}).NewSchemaSet()
+	require.NoError(t, set.Add(&examples.User{}))
+	require.NoError(t, set.Add(nested.Pet{}))
+	require.Equal(t, []string{"User", "Pet"}, set.Roots())
+
+	bundle := set.Bundle()
+	require.Equal(t, Version, bundle.Version)
+	require.Len(t, bundle.Definitions, 3)
+	require.Contains(t, bundle.Definitions, "User")
+	require.Contains(t, bundle.Definitions, "Pet")
+	require.Contains(t, bundle.Definitions, "Plant")
+}
+
+func TestSchemaSetCollision(t *testing.T) {
+	set := (&Reflector{}).NewSchemaSet()
+	require.NoError(t, set.Add(&examples.User{}))
+
+	err := set.Add(&animals.Pet{})
+	collision, ok := err.(*NameCollisionError)
+	require.True(t, ok, "expected a *NameCollisionError, got %v", err)
+	require.Equal(t, "Pet", collision.Name)
+	require.Len(t, set.Bundle().Definitions, 3, "failed Add should leave the set unchanged")
+
+	set = (&Reflector{}).NewSchemaSet()
+	require.Error(t, set.Add(&Household{}))
+
+	set = (&Reflector{FullyQualifyTypeNames: true}).NewSchemaSet()
+	require.NoError(t, set.Add(&Household{}))
+}
+
+func TestSchemaSetDocuments(t *testing.T) {
+	set := (&Reflector{}).NewSchemaSet()
+	require.NoError(t, set.Add(&examples.User{}))
+
+	docs, err := set.Documents()
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+
+	user := docs["github.com/alecthomas/jsonschema/examples/User.json"]
+	require.NotNil(t, user)
+	require.Equal(t, Version, user.Version)
+	require.Empty(t, user.ID)
+	pets, _ := user.Properties.Get("pets")
+	require.Equal(t, "nested/Pet.json", pets.(*Type).Items.Ref)
+
+	// The shared definitions must not be modified.
+	pets, _ = set.Bundle().Definitions["User"].Properties.Get("pets")
+	require.Equal(t, "#/definitions/Pet", pets.(*Type).Items.Ref)
+}
+
+func TestSchemaSetDocumentIDs(t *testing.T) {
+	r := &Reflector{
+		BaseSchemaID: "https://schemas.acme.example",
+		TypeIDer: func(t reflect.Type) string {
+			if t == reflect.TypeOf(nested.Plant{}) {
+				return "https://schemas.acme.example/plants/Plant.json"
+			}
+			return "types/" + t.Name() + ".json"
+		},
+	}
+	set := r.NewSchemaSet()
+	require.NoError(t, set.Add(&examples.User{}))
+
+	docs, err := set.Documents()
+	require.NoError(t, err)
+	require.Contains(t, docs, "types/User.json")
+	require.Contains(t, docs, "types/Pet.json")
+	require.Contains(t, docs, "plants/Plant.json")
+
+	user := docs["types/User.json"]
+	require.Equal(t, "https://schemas.acme.example/types/User.json", user.ID)
+	plants, _ := user.Properties.Get("plants")
+	require.Equal(t, "../plants/Plant.json", plants.(*Type).Items.Ref)
+
+	set.AbsoluteRefs = true
+	docs, err = set.Documents()
+	require.NoError(t, err)
+	user = docs["types/User.json"]
+	plants, _ = user.Properties.Get("plants")
+	require.Equal(t, "https://schemas.acme.example/plants/Plant.json", plants.(*Type).Items.Ref)
+
+	root := r.Reflect(&examples.User{})
+	require.Equal(t, "https://schemas.acme.example/types/User.json", root.ID)
+
+	// Draft-04 spells the keyword "id", and "$id" of later drafts is read too.
+	b, err := json.Marshal(root)
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"id":"https://schemas.acme.example/types/User.json"`)
+	require.NotContains(t, string(b), `"$id"`)
+	schema := &Schema{}
+	require.NoError(t, json.Unmarshal([]byte(`{"$id":"https://schemas.acme.example/x.json"}`), schema))
+	require.Equal(t, "https://schemas.acme.example/x.json", schema.ID)
+	require.Empty(t, schema.Extras)
+}
+
+func TestSchemaSetDuplicateIDs(t *testing.T) {
+	set := (&Reflector{TypeIDer: func(t reflect.Type) string { return "schema.json" }}).NewSchemaSet()
+	require.NoError(t, set.Add(&examples.User{}))
+
+	_, err := set.Documents()
+	require.EqualError(t, err, `jsonschema: definitions "Pet" and "Plant" have the same ID "schema.json"`)
+	require.Error(t, set.WriteDir(t.TempDir()))
+}
+
+func TestSchemaSetWriteDir(t *testing.T) {
+	set := (&Reflector{TypeIDer: func(t reflect.Type) string { return t.Name() + ".json" }}).NewSchemaSet()
+	require.NoError(t, set.Add(&examples.User{}))
+
+	dir := t.TempDir()
+	require.NoError(t, set.WriteDir(dir))
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "User.json"))
+	require.NoError(t, err)
+	schema := &Schema{}
+	require.NoError(t, json.Unmarshal(b, schema))
+	require.Equal(t, "object", schema.Type.Type)
+	require.FileExists(t, filepath.Join(dir, "Pet.json"))
+
+	set = (&Reflector{TypeIDer: func(t reflect.Type) string { return "../" + t.Name() + ".json" }}).NewSchemaSet()
+	require.NoError(t, set.Add(&nested.Pet{}))
+	require.Error(t, set.WriteDir(dir))
+}
+
+func TestRelativeRef(t *testing.T) {
+	require.Equal(t, "Pet.json", relativeRef("User.json", "Pet.json"))
+	require.Equal(t, "nested/Pet.json", relativeRef("a/User.json", "a/nested/Pet.json"))
+	require.Equal(t, "../b/Pet.json", relativeRef("a/User.json", "b/Pet.json"))
+	require.Equal(t, "https://example.com/Pet.json", relativeRef("a/User.json", "https://example.com/Pet.json"))
+}
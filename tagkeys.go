@@ -0,0 +1,184 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// A TagKey describes the tags a decoder names the fields of structs with,
+// and the options of those tags that change how it reads fields.
+type TagKey struct {
+	// Name is the key of the tags, e.g. "json".
+	Name string
+
+	// Optional lists the options of fields that aren't required, e.g.
+	// "omitempty". Other tagged fields are required.
+	Optional []string
+
+	// OptionalNilable lists the options of fields that aren't required if
+	// they are pointers or slices, e.g. the "block" option of hcl tags, as
+	// gohcl reads at most one block into a pointer and any number of blocks
+	// into a slice.
+	OptionalNilable []string
+
+	// Inline lists the options of struct fields whose fields the decoder
+	// reads as fields of the enclosing struct, e.g. "inline" or "squash".
+	Inline []string
+
+	// Remain lists the options of catch-all fields, which the decoder fills
+	// with the keys that don't belong to other fields, e.g. "remain". A
+	// catch-all field isn't a property itself but allows additional
+	// properties, described by the values of its map type if it has one.
+	Remain []string
+
+	// Quoted lists the options of fields whose booleans, numbers and
+	// strings are encoded as JSON strings, e.g. the "string" option of json
+	// tags.
+	Quoted []string
+
+	// Ignore lists the options of fields that aren't read from keys, e.g.
+	// the "label" option of hcl tags.
+	Ignore []string
+
+	// InlineAnonymous is set if the decoder reads the fields of embedded
+	// structs that aren't tagged as fields of the enclosing struct, as
+	// encoding/json does.
+	InlineAnonymous bool
+
+	// TaggedOnly is set if the decoder ignores fields that aren't tagged.
+	TaggedOnly bool
+}
+
+var (
+	// JSONTagKey describes the json tags of encoding/json.
+	JSONTagKey = TagKey{
+		Name:            "json",
+		Optional:        []string{"omitempty", "omitzero"},
+		Quoted:          []string{"string"},
+		InlineAnonymous: true,
+	}
+
+	// YAMLTagKey describes the yaml tags of gopkg.in/yaml. Fields that
+	// aren't tagged are named in lower case by yaml, see LowerCase.
+	YAMLTagKey = TagKey{Name: "yaml", Optional: []string{"omitempty"}, Inline: []string{"inline"}}
+
+	// TOMLTagKey describes the toml tags of github.com/BurntSushi/toml.
+	TOMLTagKey = TagKey{Name: "toml", Optional: []string{"omitempty", "omitzero"}, InlineAnonymous: true}
+
+	// MapstructureTagKey describes the mapstructure tags of
+	// github.com/mitchellh/mapstructure, as used by viper.
+	MapstructureTagKey = TagKey{
+		Name:     "mapstructure",
+		Optional: []string{"omitempty"},
+		Inline:   []string{"squash"},
+		Remain:   []string{"remain"},
+	}
+
+	// HCLTagKey describes the hcl tags of github.com/hashicorp/hcl/v2/gohcl.
+	HCLTagKey = TagKey{
+		Name:            "hcl",
+		Optional:        []string{"optional"},
+		OptionalNilable: []string{"block"},
+		Remain:          []string{"remain"},
+		Ignore:          []string{"label"},
+		TaggedOnly:      true,
+	}
+)
+
+// tagKeys returns the TagKeys of r. By default fields are named by their json
+// tags, or their yaml tags if they have no json tags or PreferYAMLSchema is
+// set, and embedded structs are inlined unless YAMLEmbeddedStructs is set.
+func (r *Reflector) tagKeys() []TagKey {
+	if len(r.TagKeys) > 0 {
+		return r.TagKeys
+	}
+	yamlKey := YAMLTagKey
+	yamlKey.InlineAnonymous = true
+	if r.PreferYAMLSchema {
+		return []TagKey{yamlKey}
+	}
+	return []TagKey{JSONTagKey, yamlKey}
+}
+
+// A fieldTag is the tag that names a field.
+type fieldTag struct {
+	key     TagKey
+	name    string
+	options []string
+	tagged  bool
+}
+
+// lookupFieldTag returns the tag of the first of keys that f has, or an
+// untagged fieldTag of the first key if it has none of them.
+func lookupFieldTag(f reflect.StructField, keys []TagKey) fieldTag {
+	for _, key := range keys {
+		if tag, ok := f.Tag.Lookup(key.Name); ok {
+			parts := strings.Split(tag, ",")
+			return fieldTag{key: key, name: parts[0], options: parts[1:], tagged: true}
+		}
+	}
+	return fieldTag{key: keys[0]}
+}
+
+func (t fieldTag) has(options []string) bool {
+	return hasOption(t.options, options)
+}
+
+// hasOption returns whether any of options is one of set.
+func hasOption(options, set []string) bool {
+	for _, option := range options {
+		for _, o := range set {
+			if option == o {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// optional returns whether the field, of type ft, isn't required.
+func (t fieldTag) optional(ft reflect.Type) bool {
+	if t.has(t.key.Optional) {
+		return true
+	}
+	return t.has(t.key.OptionalNilable) && (ft.Kind() == reflect.Ptr || ft.Kind() == reflect.Slice)
+}
+
+// ignored returns whether the decoder ignores the field.
+func (t fieldTag) ignored() bool {
+	if !t.tagged {
+		return t.key.TaggedOnly
+	}
+	return t.name == "-" || t.has(t.key.Ignore)
+}
+
+// inlined returns whether f is inlined by the tag of any of keys, so that
+// fields named for one decoder can be inlined for another, as fields tagged
+// `json:"x" yaml:",inline"` are.
+func inlined(f reflect.StructField, keys []TagKey) bool {
+	for _, key := range keys {
+		if tag, ok := f.Tag.Lookup(key.Name); ok {
+			if hasOption(strings.Split(tag, ",")[1:], key.Inline) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reflectRemain allows the additional properties that the catch-all field f
+// of st is filled with.
+func (r *Reflector) reflectRemain(st *Type, definitions *definitionSet, f reflect.StructField) {
+	ft := f.Type
+	for ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	st.AdditionalProperties = []byte("true")
+	if ft.Kind() != reflect.Map || ft.Key().Kind() != reflect.String || ft.Elem().Kind() == reflect.Interface {
+		return
+	}
+	if b, err := json.Marshal(r.reflectTypeToSchema(definitions, ft.Elem())); err == nil {
+		st.AdditionalProperties = b
+	}
+}
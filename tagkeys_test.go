@@ -0,0 +1,71 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type MapstructureBase struct {
+	Host string `mapstructure:"host"`
+}
+
+type MapstructureLimits struct {
+	Max int `mapstructure:"max"`
+}
+
+type MapstructureConfig struct {
+	MapstructureBase `mapstructure:",squash"`
+	Port             int                `mapstructure:"port,omitempty"`
+	Debug            bool               `json:"verbose"`
+	Limits           MapstructureLimits `mapstructure:"limits"`
+	Skipped          string             `mapstructure:"-"`
+	Extra            map[string]int     `mapstructure:",remain"`
+}
+
+type TOMLConfig struct {
+	Title   string `toml:"title"`
+	Retries int    `toml:"retries,omitzero"`
+	Owner   string
+	Ignored string `toml:"-"`
+	MapstructureBase
+}
+
+type HCLConfig struct {
+	Type    string                 `hcl:"type,label"`
+	Name    string                 `hcl:"name,attr"`
+	Region  string                 `hcl:"region,optional"`
+	Nested  *MapstructureLimits    `hcl:"nested,block"`
+	Limits  MapstructureLimits     `hcl:"limits,block"`
+	Others  []MapstructureLimits   `hcl:"other,block"`
+	Ignored string                 // gohcl ignores fields without tags.
+	Rest    map[string]interface{} `hcl:",remain"`
+}
+
+func TestTagKeys(t *testing.T) {
+	r := &Reflector{TagKeys: []TagKey{TOMLTagKey}}
+	s := r.Reflect(&TOMLConfig{}).Definitions["TOMLConfig"]
+	require.Equal(t, []string{"title", "retries", "Owner", "Host"}, propertyNames(s))
+	require.Equal(t, []string{"title", "Owner", "Host"}, s.Required)
+
+	r = &Reflector{TagKeys: []TagKey{HCLTagKey}}
+	s = r.Reflect(&HCLConfig{}).Definitions["HCLConfig"]
+	require.Equal(t, []string{"name", "region", "nested", "limits", "other"}, propertyNames(s))
+	require.Equal(t, []string{"name", "limits"}, s.Required)
+	require.Equal(t, "true", string(s.AdditionalProperties))
+
+	// Fields are named by the first of the TagKeys they have.
+	r = &Reflector{TagKeys: []TagKey{MapstructureTagKey, JSONTagKey}}
+	s = r.Reflect(&MapstructureConfig{}).Definitions["MapstructureConfig"]
+	require.Contains(t, propertyNames(s), "verbose")
+
+	// Fields of embedded structs are inlined as each TagKey describes.
+	r = &Reflector{TagKeys: []TagKey{MapstructureTagKey}}
+	s = r.Reflect(&TOMLConfig{}).Definitions["TOMLConfig"]
+	require.Equal(t, []string{"Title", "Retries", "Owner", "Ignored", "MapstructureBase"}, propertyNames(s))
+
+	// KeyNamer names untagged fields whatever the TagKeys.
+	r = &Reflector{TagKeys: []TagKey{YAMLTagKey}, KeyNamer: LowerCase}
+	s = r.Reflect(&TOMLConfig{}).Definitions["TOMLConfig"]
+	require.Equal(t, []string{"title", "retries", "owner", "ignored", "mapstructurebase"}, propertyNames(s))
+}
@@ -0,0 +1,208 @@
+package jsonschema
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A TagReader reads keywords from struct tags other than jsonschema tags,
+// such as those of validation libraries, so that the rules they give needn't
+// be repeated in jsonschema tags.
+type TagReader interface {
+	// ReadTags returns the keywords given by the tags of f, written as in
+	// jsonschema tags, e.g. "minLength=1" or "enum=a". The keyword
+	// "required" requires the property reflected from f, whatever the
+	// options of the Reflector. Keywords following the keyword "dive" apply
+	// to the items of the property rather than to the property itself.
+	ReadTags(f reflect.StructField) []string
+}
+
+// readTags returns the keywords the TagReaders of r read from f.
+func (r *Reflector) readTags(f reflect.StructField) []string {
+	var tags []string
+	for _, reader := range r.TagReaders {
+		tags = append(tags, reader.ReadTags(f)...)
+	}
+	return tags
+}
+
+// splitDive splits the keywords read by TagReaders into those of a property
+// and those of its items. Items can't be required, so "required" is dropped
+// from the keywords of items.
+func splitDive(tags []string) (property, items []string) {
+	for i, tag := range tags {
+		if tag == "dive" {
+			for _, tag := range tags[i+1:] {
+				if tag != "required" {
+					items = append(items, tag)
+				}
+			}
+			return tags[:i:i], items
+		}
+	}
+	return tags, nil
+}
+
+// hasKeyword returns whether tags give the keyword with the given name.
+func hasKeyword(tags []string, name string) bool {
+	for _, tag := range tags {
+		if strings.SplitN(tag, "=", 2)[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutKeyword returns tags without those giving the keyword with the
+// given name.
+func withoutKeyword(tags []string, name string) []string {
+	var without []string
+	for _, tag := range tags {
+		if strings.SplitN(tag, "=", 2)[0] != name {
+			without = append(without, tag)
+		}
+	}
+	return without
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTagReader is a TagReader of the validate tags of
+// github.com/go-playground/validator. It maps the rules required, min, max,
+// len, gt, gte, lt, lte, oneof, email, url, uri, uuid, hostname, ipv4,
+// ipv6 and dive onto keywords according to the type of the field, so that
+// e.g. min bounds the length of strings, the value of numbers and the number
+// of items of slices. Other rules, and alternatives joined with "|", are
+// ignored.
+type ValidateTagReader struct {
+	// TagName is the name of the tag to read, "validate" if empty.
+	TagName string
+}
+
+// ReadTags implements TagReader.
+func (v ValidateTagReader) ReadTags(f reflect.StructField) []string {
+	name := v.TagName
+	if name == "" {
+		name = "validate"
+	}
+	tag, ok := f.Tag.Lookup(name)
+	if !ok || tag == "-" {
+		return nil
+	}
+
+	var keywords []string
+	t := f.Type
+	inKeys := false
+	for _, rule := range strings.Split(tag, ",") {
+		rule = strings.TrimSpace(rule)
+		switch {
+		case inKeys:
+			// Rules of the keys of maps have no keywords.
+			inKeys = rule != "endkeys"
+			continue
+		case rule == "keys":
+			inKeys = true
+			continue
+		case strings.Contains(rule, "|"):
+			continue
+		case rule == "dive":
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+				// Keywords of the values of maps can't be given.
+				return keywords
+			}
+			t = t.Elem()
+			keywords = append(keywords, "dive")
+			continue
+		}
+		nameParam := strings.SplitN(rule, "=", 2)
+		param := ""
+		if len(nameParam) == 2 {
+			param = nameParam[1]
+		}
+		keywords = append(keywords, validateKeywords(t, nameParam[0], param)...)
+	}
+	return keywords
+}
+
+// validateParams matches the space separated parameters of validate rules,
+// which can be quoted with single quotes to contain spaces.
+var validateParams = regexp.MustCompile(`'[^']*'|\S+`)
+
+// validateKeywords returns the keywords of the validate rule with the given
+// name and parameter, applied to a value of type t.
+func validateKeywords(t reflect.Type, name, param string) []string {
+	switch name {
+	case "required":
+		return []string{"required"}
+	case "email":
+		return []string{"format=email"}
+	case "url", "uri":
+		return []string{"format=uri"}
+	case "uuid":
+		return []string{"format=uuid"}
+	case "hostname", "ipv4", "ipv6":
+		return []string{"format=" + name}
+	case "oneof":
+		var keywords []string
+		for _, value := range validateParams.FindAllString(param, -1) {
+			keywords = append(keywords, "enum="+strings.Trim(value, "'"))
+		}
+		return keywords
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var minimum, maximum string
+	exclusive := false
+	switch t.Kind() {
+	case reflect.String:
+		minimum, maximum = "minLength", "maxLength"
+	case reflect.Slice, reflect.Array:
+		minimum, maximum = "minItems", "maxItems"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		minimum, maximum = "minimum", "maximum"
+	case reflect.Float32, reflect.Float64:
+		minimum, maximum = "minimum", "maximum"
+		exclusive = true
+	default:
+		return nil
+	}
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return nil
+	}
+
+	switch name {
+	case "min", "gte":
+		return []string{minimum + "=" + param}
+	case "max", "lte":
+		return []string{maximum + "=" + param}
+	case "len":
+		return []string{minimum + "=" + param, maximum + "=" + param}
+	case "gt":
+		if exclusive {
+			return []string{minimum + "=" + param, "exclusiveMinimum=true"}
+		}
+		return []string{minimum + "=" + strconv.Itoa(n+1)}
+	case "lt":
+		if exclusive {
+			return []string{maximum + "=" + param, "exclusiveMaximum=true"}
+		}
+		return []string{maximum + "=" + strconv.Itoa(n-1)}
+	}
+	return nil
+}
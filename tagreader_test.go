@@ -0,0 +1,61 @@
+package jsonschema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type ValidatedUser struct {
+	Name     string            `json:"name,omitempty" validate:"required,min=1,max=20"`
+	Email    string            `json:"email,omitempty" validate:"omitempty,email"`
+	Website  string            `json:"website,omitempty" validate:"url"`
+	ID       string            `json:"id" validate:"uuid"`
+	Code     string            `json:"code" validate:"len=4"`
+	Role     string            `json:"role" validate:"oneof=admin 'read only' guest"`
+	Age      int               `json:"age" validate:"gt=0,lt=150"`
+	Rating   *float64          `json:"rating,omitempty" validate:"gte=1,lte=5"`
+	Tags     []string          `json:"tags" validate:"min=1,max=10,dive,min=2,oneof=a b"`
+	Ratio    float64           `json:"ratio" validate:"gt=0,lt=1"`
+	Scores   []int             `json:"scores" validate:"dive,lte=100"`
+	Nickname string            `json:"nickname" validate:"max=10" jsonschema:"maxLength=8"`
+	Contact  string            `json:"contact" validate:"email|url,min=3"`
+	Labels   map[string]string `json:"labels" validate:"dive,keys,min=1,endkeys,required"`
+	Level    string            `json:"level" validate:"oneof=low high" jsonschema:"enum=low,enum=medium,enum=high"`
+	Aliases  []string          `json:"aliases,omitempty" validate:"dive,required,min=1"`
+}
+
+func TestValidateTagReader(t *testing.T) {
+	tests := []struct {
+		field    string
+		expected []string
+	}{
+		{"Name", []string{"required", "minLength=1", "maxLength=20"}},
+		{"Email", []string{"format=email"}},
+		{"Website", []string{"format=uri"}},
+		{"ID", []string{"format=uuid"}},
+		{"Code", []string{"minLength=4", "maxLength=4"}},
+		{"Role", []string{"enum=admin", "enum=read only", "enum=guest"}},
+		{"Age", []string{"minimum=1", "maximum=149"}},
+		{"Rating", []string{"minimum=1", "maximum=5"}},
+		{"Ratio", []string{"minimum=0", "exclusiveMinimum=true", "maximum=1", "exclusiveMaximum=true"}},
+		{"Tags", []string{"minItems=1", "maxItems=10", "dive", "minLength=2", "enum=a", "enum=b"}},
+		{"Scores", []string{"dive", "maximum=100"}},
+		{"Contact", []string{"minLength=3"}},
+		{"Labels", nil},
+		{"Aliases", []string{"dive", "required", "minLength=1"}},
+	}
+	typ := reflect.TypeOf(ValidatedUser{})
+	for _, test := range tests {
+		f, ok := typ.FieldByName(test.field)
+		require.True(t, ok, test.field)
+		require.Equal(t, test.expected, ValidateTagReader{}.ReadTags(f), test.field)
+	}
+
+	f, _ := reflect.TypeOf(struct {
+		Name string `binding:"required,max=3"`
+	}{}).FieldByName("Name")
+	require.Equal(t, []string{"required", "maxLength=3"}, ValidateTagReader{TagName: "binding"}.ReadTags(f))
+	require.Empty(t, ValidateTagReader{}.ReadTags(f))
+}
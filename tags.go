@@ -0,0 +1,177 @@
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// splitTag splits a jsonschema tag into its comma separated keywords. Commas
+// within brackets, braces or the strings inside them don't separate
+// keywords, so that values can be JSON literals such as `default={"a":1,"b":2}`.
+// Tags in which these aren't balanced are split on every comma.
+func splitTag(tag string) []string {
+	var (
+		parts    []string
+		start    int
+		depth    int
+		inString bool
+		escaped  bool
+	)
+	for i, c := range tag {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"' && depth > 0:
+			inString = !inString
+		case inString:
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, tag[start:i])
+			start = i + 1
+		}
+		if depth < 0 {
+			break
+		}
+	}
+	if depth != 0 || inString {
+		return strings.Split(tag, ",")
+	}
+	return append(parts, tag[start:])
+}
+
+// defaultKeyword sets the default of the property reflected from f to the
+// values of its default tags, parsed according to the type of f. A field
+// with a slice or array type can either repeat the tag once per element, or
+// give a single JSON array. Struct, map and interface fields take JSON
+// literals. Defaults that can't be parsed are left out of the schema, and the
+// error parsing them is returned.
+func (t *Type) defaultKeyword(tags []string, f reflect.StructField) error {
+	var defaults []string
+	for _, tag := range tags {
+		if nameValue := strings.SplitN(tag, "=", 2); len(nameValue) == 2 && nameValue[0] == "default" {
+			defaults = append(defaults, nameValue[1])
+		}
+	}
+	if len(defaults) == 0 {
+		return nil
+	}
+
+	ft := f.Type
+	for ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	var (
+		def interface{}
+		err error
+	)
+	switch {
+	case (ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array) && ft.Elem().Kind() != reflect.Uint8 &&
+		!(len(defaults) == 1 && strings.HasPrefix(defaults[0], "[")):
+		values := make([]interface{}, len(defaults))
+		for i, d := range defaults {
+			if values[i], err = parseDefault(ft.Elem(), d); err != nil {
+				break
+			}
+		}
+		def = values
+	case len(defaults) > 1:
+		err = fmt.Errorf("%d defaults given for a single value", len(defaults))
+	default:
+		def, err = parseDefault(ft, defaults[0])
+	}
+	if err != nil {
+		return &InvalidDefaultError{Field: f.Name, Defaults: defaults, Err: err}
+	}
+	t.Default = def
+	return nil
+}
+
+// An InvalidDefaultError reports that the default tags of a field couldn't be
+// parsed as values of its type.
+type InvalidDefaultError struct {
+	Type     reflect.Type
+	Field    string
+	Defaults []string
+	Err      error
+}
+
+func (e *InvalidDefaultError) Error() string {
+	return fmt.Sprintf("jsonschema: invalid default %q of field %s.%s: %v",
+		strings.Join(e.Defaults, ","), fullyQualifiedTypeName(e.Type), e.Field, e.Err)
+}
+
+func (e *InvalidDefaultError) Unwrap() error {
+	return e.Err
+}
+
+// parseDefault parses the default s of a value of type t.
+func parseDefault(t reflect.Type, s string) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == durationType {
+		// Durations are marshalled as nanoseconds.
+		if d, err := time.ParseDuration(s); err == nil {
+			return int64(d), nil
+		}
+		return strconv.ParseInt(s, 10, 64)
+	}
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		v := reflect.New(t).Interface().(encoding.TextUnmarshaler)
+		if err := v.UnmarshalText([]byte(s)); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, t.Bits())
+		return int(i), err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.ParseUint(s, 10, t.Bits())
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(s, t.Bits())
+	case reflect.String:
+		return s, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Byte slices are marshalled as base64 strings.
+			return s, nil
+		}
+	case reflect.Interface:
+		// Anything goes, so values that aren't JSON are taken as strings.
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return s, nil
+		}
+		return v, nil
+	}
+
+	// Other types take JSON literals, which must also decode to t.
+	if err := json.Unmarshal([]byte(s), reflect.New(t).Interface()); err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,147 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitTag(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected []string
+	}{
+		{"", []string{""}},
+		{"required,minLength=1", []string{"required", "minLength=1"}},
+		{`default={"a":1,"b":[2,3]},required`, []string{`default={"a":1,"b":[2,3]}`, "required"}},
+		{`default=[1,2],default={"s":"],}"}`, []string{"default=[1,2]", `default={"s":"],}"}`}},
+		{`default={"s":"\",{"}`, []string{`default={"s":"\",{"}`}},
+		{"pattern=^[a-z]{1,3}$,title=x", []string{"pattern=^[a-z]{1,3}$", "title=x"}},
+		// Unbalanced tags are split on every comma, as they always were.
+		{"pattern=^[^\"],title=x", []string{"pattern=^[^\"]", "title=x"}},
+		{"pattern=a]b,title=x", []string{"pattern=a]b", "title=x"}},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, splitTag(test.tag), test.tag)
+	}
+}
+
+type TypedDefaults struct {
+	Enabled  bool                   `json:"enabled" jsonschema:"default=true"`
+	Ratio    float64                `json:"ratio" jsonschema:"default=0.75"`
+	Small    float32                `json:"small" jsonschema:"default=1.5"`
+	Count    uint16                 `json:"count" jsonschema:"default=8"`
+	Timeout  time.Duration          `json:"timeout" jsonschema:"default=1m30s"`
+	Interval *time.Duration         `json:"interval" jsonschema:"default=1000"`
+	Weights  []float64              `json:"weights" jsonschema:"default=0.5,default=1.5"`
+	Flags    []bool                 `json:"flags" jsonschema:"default=[true,false]"`
+	Ports    [2]int                 `json:"ports" jsonschema:"default=80,default=443"`
+	Labels   map[string]int         `json:"labels" jsonschema:"default={\"a\":1,\"b\":2}"`
+	Point    Point                  `json:"point" jsonschema:"default={\"x\":1,\"y\":2}"`
+	Since    time.Time              `json:"since" jsonschema:"default=2006-01-02T15:04:05Z"`
+	Server   net.IP                 `json:"server" jsonschema:"default=192.0.2.1"`
+	Data     []byte                 `json:"data" jsonschema:"default=aGk="`
+	Any      interface{}            `json:"any" jsonschema:"default=[1,\"two\"]"`
+	Text     interface{}            `json:"text" jsonschema:"default=plain text"`
+	Name     string                 `json:"name" jsonschema:"default=a=b"`
+	Optional *bool                  `json:"optional" jsonschema:"default=false,nullable"`
+	Extra    map[string]interface{} `json:"extra,omitempty"`
+}
+
+func TestTypedDefaults(t *testing.T) {
+	s := (&Reflector{DoNotReference: true, ExpandedStruct: true}).Reflect(&TypedDefaults{})
+	defaults := map[string]interface{}{}
+	for name, p := range propertyMap(s.Type) {
+		if p.Default == nil && len(p.OneOf) > 0 {
+			p = p.OneOf[0]
+		}
+		if p.Default != nil {
+			defaults[name] = p.Default
+		}
+	}
+	b, err := json.Marshal(defaults)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"enabled": true,
+		"ratio": 0.75,
+		"small": 1.5,
+		"count": 8,
+		"timeout": 90000000000,
+		"interval": 1000,
+		"weights": [0.5, 1.5],
+		"flags": [true, false],
+		"ports": [80, 443],
+		"labels": {"a": 1, "b": 2},
+		"point": {"x": 1, "y": 2},
+		"since": "2006-01-02T15:04:05Z",
+		"server": "192.0.2.1",
+		"data": "aGk=",
+		"any": [1, "two"],
+		"text": "plain text",
+		"name": "a=b",
+		"optional": false
+	}`, string(b))
+
+	// The typed defaults can be applied back to the fields they came from.
+	var d TypedDefaults
+	require.NoError(t, ApplyDefaults(&d))
+	require.Equal(t, 90*time.Second, d.Timeout)
+	require.Equal(t, time.Microsecond, *d.Interval)
+	require.Equal(t, [2]int{80, 443}, d.Ports)
+	require.Equal(t, Point{X: 1, Y: 2}, d.Point)
+	require.Equal(t, "192.0.2.1", d.Server.String())
+	require.Equal(t, []byte("hi"), d.Data)
+}
+
+func TestInvalidTypedDefaults(t *testing.T) {
+	type BadInt struct {
+		V int `json:"v" jsonschema:"default=many"`
+	}
+	type BadUint struct {
+		V uint8 `json:"v" jsonschema:"default=256"`
+	}
+	type BadBool struct {
+		V bool `json:"v" jsonschema:"default=maybe"`
+	}
+	type BadDuration struct {
+		V time.Duration `json:"v" jsonschema:"default=5 parsecs"`
+	}
+	type BadElement struct {
+		V []int `json:"v" jsonschema:"default=1,default=two"`
+	}
+	type BadStruct struct {
+		V Point `json:"v" jsonschema:"default={\"x\":\"one\"}"`
+	}
+	type BadTime struct {
+		V time.Time `json:"v" jsonschema:"default=yesterday"`
+	}
+	type TooMany struct {
+		V string `json:"v" jsonschema:"default=a,default=b"`
+	}
+	for _, v := range []interface{}{
+		&BadInt{}, &BadUint{}, &BadBool{}, &BadDuration{}, &BadElement{}, &BadStruct{}, &BadTime{}, &TooMany{},
+	} {
+		r := &Reflector{ExpandedStruct: true}
+		s, err := r.ReflectStrict(v)
+		var invalid *InvalidDefaultError
+		require.True(t, errors.As(err, &invalid), "%T: %v", v, err)
+		require.Equal(t, reflect.TypeOf(v).Elem(), invalid.Type)
+		require.Equal(t, "V", invalid.Field)
+		p, _ := s.Properties.Get("v")
+		require.Nil(t, p.(*Type).Default, "%T", v)
+
+		// Invalid defaults are reported however often they are reflected.
+		r.Cache = true
+		_, err = r.ReflectStrict(v)
+		require.Error(t, err)
+		_, err = r.ReflectStrict(v)
+		require.Error(t, err)
+	}
+	_, err := (&Reflector{}).ReflectStrict(&BadInt{})
+	require.EqualError(t, err, `jsonschema: invalid default "many" of field `+fullyQualifiedTypeName(reflect.TypeOf(BadInt{}))+`.V: strconv.ParseInt: parsing "many": invalid syntax`)
+}
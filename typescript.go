@@ -0,0 +1,342 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/iancoleman/orderedmap"
+)
+
+// WriteTypeScript writes TypeScript declarations of the types described by s
+// to w, so that clients can share the types of the Go structs s was reflected
+// from. Every definition is declared as an exported interface if it is an
+// object with properties, or as a type alias otherwise. A root schema that
+// isn't a reference to a definition is declared too, named after its title,
+// or "Schema" if it has none. Declarations are ordered as described for
+// WriteMarkdown.
+//
+// Definition names are turned into identifiers by capitalising each of their
+// parts, so that "github.com/org/pkg.User" becomes "GithubComOrgPkgUser".
+// Enums become unions of literals, lists of types and oneOf and anyOf unions
+// of their alternatives, allOf intersections, and patternProperties and
+// additionalProperties index signatures. Properties that aren't required are
+// optional, and objects with alternatives, such as those of oneof_required,
+// are intersections of their properties with the alternatives. Descriptions
+// become JSDoc comments.
+func WriteTypeScript(w io.Writer, s *Schema) error {
+	e := &typeScriptEmitter{names: typeScriptNames(s)}
+	root := s.Type
+	if root == nil {
+		root = &Type{}
+	}
+	if name := strings.TrimPrefix(root.Ref, definitionsPrefix); name == root.Ref || s.Definitions[name] == nil {
+		rootName := typeScriptIdentifier(root.Title)
+		if rootName == "" {
+			rootName = "Schema"
+		}
+		for e.taken(rootName) {
+			rootName = "_" + rootName
+		}
+		e.declare(rootName, root)
+	}
+	for _, name := range definitionOrder(s) {
+		e.declare(e.names[name], s.Definitions[name])
+	}
+	_, err := w.Write(e.b.Bytes())
+	return err
+}
+
+type typeScriptEmitter struct {
+	b     bytes.Buffer
+	names map[string]string
+}
+
+func (e *typeScriptEmitter) taken(identifier string) bool {
+	for _, name := range e.names {
+		if name == identifier {
+			return true
+		}
+	}
+	return false
+}
+
+// typeScriptNames returns the identifiers to declare the definitions of s
+// with, adding a number to those that would otherwise clash.
+func typeScriptNames(s *Schema) map[string]string {
+	names := map[string]string{}
+	used := map[string]bool{}
+	for _, name := range sortedDefinitionNames(s.Definitions) {
+		identifier := typeScriptIdentifier(name)
+		if identifier == "" {
+			identifier = "Definition"
+		}
+		unique := identifier
+		for i := 2; used[unique]; i++ {
+			unique = fmt.Sprintf("%s%d", identifier, i)
+		}
+		used[unique] = true
+		names[name] = unique
+	}
+	return names
+}
+
+var typeScriptSeparators = regexp.MustCompile(`[^\p{L}\p{N}_$]+`)
+
+// typeScriptIdentifier turns name into a TypeScript identifier.
+func typeScriptIdentifier(name string) string {
+	var b strings.Builder
+	for _, part := range typeScriptSeparators.Split(name, -1) {
+		if part != "" {
+			b.WriteString(upperFirst(part))
+		}
+	}
+	identifier := b.String()
+	if identifier != "" && unicode.IsDigit([]rune(identifier)[0]) {
+		identifier = "_" + identifier
+	}
+	return identifier
+}
+
+var typeScriptPropertyName = regexp.MustCompile(`^[\p{L}_$][\p{L}\p{N}_$]*$`)
+
+func (e *typeScriptEmitter) declare(name string, t *Type) {
+	if e.b.Len() > 0 {
+		e.b.WriteString("\n")
+	}
+	e.b.WriteString(typeScriptComment("", t.Description))
+	if t.Ref == "" && t.Properties != nil && (t.Type == "object" || t.Type == "") &&
+		len(t.OneOf)+len(t.AnyOf)+len(t.AllOf)+len(t.Enum)+len(t.AdditionalTypes) == 0 {
+		fmt.Fprintf(&e.b, "export interface %s %s\n", name, e.object(t, ""))
+		return
+	}
+	fmt.Fprintf(&e.b, "export type %s = %s;\n", name, e.typeOf(t, ""))
+}
+
+// typeScriptComment returns description as a JSDoc comment at the given indentation.
+func typeScriptComment(indent, description string) string {
+	description = strings.TrimSpace(strings.Replace(description, "*/", `*\/`, -1))
+	if description == "" {
+		return ""
+	}
+	lines := strings.Split(description, "\n")
+	if len(lines) == 1 {
+		return fmt.Sprintf("%s/** %s */\n", indent, lines[0])
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s\n", strings.TrimRight(indent+" * "+line, " "))
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+	return b.String()
+}
+
+// object returns the object type literal of t, whose closing brace is at
+// the given indentation.
+func (e *typeScriptEmitter) object(t *Type, indent string) string {
+	inner := indent + "  "
+	b := &strings.Builder{}
+	b.WriteString("{\n")
+	required := stringSet(t.Required)
+	properties := propertyMap(t)
+	for _, name := range propertyNames(t) {
+		p := properties[name]
+		key := name
+		if !typeScriptPropertyName.MatchString(name) {
+			quoted, _ := json.Marshal(name)
+			key = string(quoted)
+		}
+		optional := "?"
+		if required[name] {
+			optional = ""
+		}
+		if p != nil {
+			b.WriteString(typeScriptComment(inner, p.Description))
+		}
+		fmt.Fprintf(b, "%s%s%s: %s;\n", inner, key, optional, e.typeOf(p, inner))
+	}
+	if index := e.indexType(t, inner); index != "" {
+		fmt.Fprintf(b, "%s[key: string]: %s;\n", inner, index)
+	}
+	fmt.Fprintf(b, "%s}", indent)
+	return b.String()
+}
+
+// indexType returns the type of the index signature of the object t, or ""
+// if it doesn't need one.
+func (e *typeScriptEmitter) indexType(t *Type, indent string) string {
+	var values []*Type
+	for _, pattern := range sortedDefinitionNames(t.PatternProperties) {
+		values = append(values, t.PatternProperties[pattern])
+	}
+	if additional := additionalPropertiesSchema(t); additional != nil {
+		values = append(values, additional)
+	}
+	switch {
+	case len(values) == 0:
+		return ""
+	case len(propertyNames(t)) > 0:
+		// The types of properties must be assignable to the index signature.
+		return "unknown"
+	}
+	return e.union(values, indent)
+}
+
+// union returns the union of the types of alternatives, without repeats.
+func (e *typeScriptEmitter) union(alternatives []*Type, indent string) string {
+	return strings.Join(e.alternatives(alternatives, indent), " | ")
+}
+
+// alternatives returns the types of alternatives, without repeats.
+func (e *typeScriptEmitter) alternatives(alternatives []*Type, indent string) []string {
+	var types []string
+	seen := map[string]bool{}
+	for _, a := range alternatives {
+		if ts := e.typeOf(a, indent); !seen[ts] {
+			seen[ts] = true
+			types = append(types, ts)
+		}
+	}
+	return types
+}
+
+// typeOf returns the TypeScript type of t. Object type literals within it are
+// indented as if t started at the given indentation.
+func (e *typeScriptEmitter) typeOf(t *Type, indent string) string {
+	if t == nil {
+		return "unknown"
+	}
+	if name := strings.TrimPrefix(t.Ref, definitionsPrefix); name != t.Ref {
+		if identifier, ok := e.names[name]; ok {
+			return identifier
+		}
+	}
+	if t.Ref != "" {
+		return "unknown"
+	}
+	if len(t.Enum) > 0 {
+		literals := make([]string, 0, len(t.Enum))
+		for _, v := range t.Enum {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return "unknown"
+			}
+			literals = append(literals, string(b))
+		}
+		return strings.Join(literals, " | ")
+	}
+
+	switch {
+	case len(t.OneOf) > 0:
+		return e.withProperties(t, e.alternatives(requiredVariants(t, t.OneOf), indent), " | ", indent)
+	case len(t.AnyOf) > 0:
+		return e.withProperties(t, e.alternatives(requiredVariants(t, t.AnyOf), indent), " | ", indent)
+	case len(t.AllOf) > 0:
+		parts := make([]string, len(t.AllOf))
+		for i, a := range requiredVariants(t, t.AllOf) {
+			parts[i] = e.parenthesize(e.typeOf(a, indent))
+		}
+		return e.withProperties(t, parts, " & ", indent)
+	}
+
+	if len(t.AdditionalTypes) > 0 {
+		return e.union(typeAlternatives(t), indent)
+	}
+
+	switch t.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "null":
+		return "null"
+	case "array":
+		return e.parenthesize(e.typeOf(t.Items, indent)) + "[]"
+	case "object", "":
+		if len(propertyNames(t)) > 0 {
+			return e.object(t, indent)
+		}
+		if index := e.indexType(t, indent); index != "" {
+			return fmt.Sprintf("Record<string, %s>", index)
+		}
+		if t.Type == "object" {
+			return "Record<string, unknown>"
+		}
+	}
+	return "unknown"
+}
+
+// withProperties returns the types of the alternatives of t joined by sep,
+// intersected with the object type literal of the properties of t if it has
+// any.
+func (e *typeScriptEmitter) withProperties(t *Type, types []string, sep, indent string) string {
+	ts := strings.Join(types, sep)
+	if len(propertyNames(t)) == 0 {
+		return ts
+	}
+	object := e.object(t, indent)
+	switch {
+	case ts == "unknown":
+		return object
+	case sep == " | " && len(types) > 1:
+		ts = "(" + ts + ")"
+	case len(types) == 1:
+		ts = e.parenthesize(ts)
+	}
+	return object + " & " + ts
+}
+
+// requiredVariants returns alternatives, with those that only require some
+// of the properties of t, as oneof_required gives them, replaced by objects
+// of those properties, so that the alternatives of
+//
+//	{"properties": {"a": {"type": "string"}}, "oneOf": [{"required": ["a"]}]}
+//
+// become {a: string}.
+func requiredVariants(t *Type, alternatives []*Type) []*Type {
+	properties := propertyMap(t)
+	variants := make([]*Type, len(alternatives))
+	for i, a := range alternatives {
+		variants[i] = a
+		if a == nil || len(a.Required) == 0 || a.Type != "" || a.Ref != "" || a.Properties != nil ||
+			len(a.OneOf)+len(a.AnyOf)+len(a.AllOf)+len(a.Enum) > 0 {
+			continue
+		}
+		variant := &Type{Type: "object", Properties: orderedmap.New(), Required: a.Required}
+		for _, name := range a.Required {
+			variant.Properties.Set(name, properties[name])
+		}
+		variants[i] = variant
+	}
+	return variants
+}
+
+// typeAlternatives returns copies of t, one for each of its types.
+func typeAlternatives(t *Type) []*Type {
+	var alternatives []*Type
+	for _, typ := range t.types() {
+		c := *t
+		c.Type, c.AdditionalTypes = typ, nil
+		alternatives = append(alternatives, &c)
+	}
+	return alternatives
+}
+
+// parenthesize wraps unions and intersections in parentheses, for use as the
+// element type of an array or part of an intersection.
+func (e *typeScriptEmitter) parenthesize(ts string) string {
+	if strings.HasPrefix(ts, "{") {
+		return ts
+	}
+	if strings.Contains(ts, " | ") || strings.Contains(ts, " & ") {
+		return "(" + ts + ")"
+	}
+	return ts
+}
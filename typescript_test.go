@@ -0,0 +1,94 @@
+package jsonschema
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type TSAddress struct {
+	Street string `json:"street" jsonschema:"description=Street and number"`
+	City   string `json:"city,omitempty"`
+}
+
+type TSContact struct {
+	Name     string              `json:"name"`
+	Kind     string              `json:"kind" jsonschema:"enum=person,enum=company"`
+	Priority int                 `json:"priority,omitempty" jsonschema:"enum=1,enum=2,enum=3"`
+	Address  *TSAddress          `json:"address,omitempty" jsonschema:"nullable"`
+	Previous []TSAddress         `json:"previous,omitempty"`
+	Scores   map[string]float64  `json:"scores,omitempty"`
+	Groups   map[string][]string `json:"groups,omitempty"`
+	Extra    interface{}         `json:"extra,omitempty"`
+	Legacy   bool                `json:"legacy-id,omitempty" jsonschema:"description=Deprecated.\nUse name instead."`
+	Inline   struct {
+		Note string `json:"note"`
+	} `json:"inline"`
+}
+
+func TestWriteTypeScript(t *testing.T) {
+	r := &Reflector{FullyQualifyTypeNames: true}
+	s := r.Reflect(&TSContact{})
+	b := &bytes.Buffer{}
+	require.NoError(t, WriteTypeScript(b, s))
+	expected, err := ioutil.ReadFile("fixtures/typescript.ts")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), b.String())
+}
+
+func TestWriteTypeScriptOneOfRequired(t *testing.T) {
+	r := &Reflector{RequiredFromJSONSchemaTags: true}
+	s := r.Reflect(&RootOneOf{})
+	b := &bytes.Buffer{}
+	require.NoError(t, WriteTypeScript(b, s))
+	expected, err := ioutil.ReadFile("fixtures/typescript_oneof.ts")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), b.String())
+}
+
+func TestWriteTypeScriptSchema(t *testing.T) {
+	s := &Schema{
+		Type: &Type{
+			Title: "order request",
+			Type:  "object",
+			Properties: props(
+				"id", &Type{OneOf: []*Type{{Type: "string"}, {Type: "integer"}}},
+				"items", &Type{Type: "array", Items: &Type{AnyOf: []*Type{{Ref: "#/definitions/a.Item"}, {Ref: "#/definitions/b.Item"}}}},
+				"meta", &Type{AllOf: []*Type{{Ref: "#/definitions/a.Item"}, {Type: "object", PatternProperties: map[string]*Type{"^x-": {Type: "string"}}}}},
+				"extra", &Type{Type: "object", Properties: props("n", &Type{Type: "number"}), AdditionalProperties: []byte(`{"type":"string"}`)},
+			),
+			Required: []string{"id"},
+		},
+		Definitions: Definitions{
+			"a.Item": {Type: "object", Properties: props("sku", &Type{Type: "string"})},
+			"b.Item": {Type: "string", Description: "An item by name. */"},
+		},
+	}
+	b := &bytes.Buffer{}
+	require.NoError(t, WriteTypeScript(b, s))
+	require.Equal(t, `export interface OrderRequest {
+  id: string | number;
+  items?: (AItem | BItem)[];
+  meta?: AItem & Record<string, string>;
+  extra?: {
+    n?: number;
+    [key: string]: unknown;
+  };
+}
+
+export interface AItem {
+  sku?: string;
+}
+
+/** An item by name. *\/ */
+export type BItem = string;
+`, b.String())
+}
+
+func TestTypeScriptIdentifier(t *testing.T) {
+	require.Equal(t, "GithubComOrgPkgUser", typeScriptIdentifier("github.com/org/pkg.User"))
+	require.Equal(t, "_2fa", typeScriptIdentifier("2fa"))
+	require.Equal(t, "", typeScriptIdentifier("..."))
+}
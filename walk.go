@@ -0,0 +1,274 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/orderedmap"
+)
+
+// SkipSubschemas is used as a return value from a Visitor or Transformer to
+// indicate that the subschemas of the schema it was called with are to be
+// skipped. It is not returned as an error by any function.
+var SkipSubschemas = errors.New("skip subschemas")
+
+// A Visitor is called by Walk for each schema visited, with the JSON Pointer
+// to it from the root of the walk and its parent schema, which is nil for the
+// root. Returning SkipSubschemas skips the subschemas of t, and returning
+// any other error stops the walk.
+type Visitor func(ptr string, parent, t *Type) error
+
+// Walk calls v for t and every subschema of t, depth first and in keyword
+// order, with properties visited in their own order and the keys of other
+// maps in sorted order. References are not followed. Walk returns the first
+// error returned by v, other than SkipSubschemas.
+//
+// v may modify the schemas it is called with. A subschema of
+// additionalProperties is decoded from JSON for v, and encoded back into its
+// parent if v or the visits of its own subschemas changed it.
+func Walk(t *Type, v Visitor) error {
+	return walk("", nil, t, v)
+}
+
+// WalkSchema walks the root of s, then each of its definitions in name
+// order, with pointers relative to the document.
+func WalkSchema(s *Schema, v Visitor) error {
+	if err := Walk(s.Type, v); err != nil {
+		return err
+	}
+	for _, name := range sortedDefinitionNames(s.Definitions) {
+		if err := walk("/definitions/"+escapePointer(name), nil, s.Definitions[name], v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walk(ptr string, parent, t *Type, v Visitor) error {
+	if t == nil {
+		return nil
+	}
+	if err := v(ptr, parent, t); err == SkipSubschemas {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var err error
+	t.eachSubschema(func(sub string, c *Type) {
+		if err == nil {
+			err = walk(ptr+sub, t, c, v)
+		}
+	})
+	return err
+}
+
+// A Transformer is called by Transform for each schema, with the JSON Pointer
+// to it from the root of the transformation and its parent schema, which is
+// nil for the root. It returns the schema to use in place of t, which is
+// either t itself, a replacement, or nil to remove t from its parent. The
+// subschemas of the returned schema are transformed next, unless
+// SkipSubschemas is returned. Returning any other error stops the
+// transformation.
+//
+// The parent passed is the schema returned for it, before its subschemas
+// were transformed.
+type Transformer func(ptr string, parent, t *Type) (*Type, error)
+
+// Transform returns a copy of t transformed by calling fn for t and each of
+// its subschemas, top down and in the same order as Walk.
+//
+// The schemas passed to fn are those of the original tree, which Transform
+// itself never modifies, so to change a schema fn should return a modified
+// copy of it rather than update it in place, unless changing the original is
+// intended:
+//
+//	jsonschema.Transform(t, func(ptr string, parent, t *jsonschema.Type) (*jsonschema.Type, error) {
+//	    c := *t
+//	    c.Description = strings.TrimSpace(c.Description)
+//	    return &c, nil
+//	})
+func Transform(t *Type, fn Transformer) (*Type, error) {
+	return transform("", nil, t, fn)
+}
+
+// TransformSchema transforms the root of s and each of its definitions,
+// returning a new Schema. Definitions removed by fn are dropped.
+func TransformSchema(s *Schema, fn Transformer) (*Schema, error) {
+	root, err := Transform(s.Type, fn)
+	if err != nil {
+		return nil, err
+	}
+	var definitions Definitions
+	if s.Definitions != nil {
+		definitions = Definitions{}
+		for _, name := range sortedDefinitionNames(s.Definitions) {
+			def, err := transform("/definitions/"+escapePointer(name), nil, s.Definitions[name], fn)
+			if err != nil {
+				return nil, err
+			}
+			if def != nil {
+				definitions[name] = def
+			}
+		}
+	}
+	return &Schema{Type: root, Definitions: definitions}, nil
+}
+
+func transform(ptr string, parent, t *Type, fn Transformer) (*Type, error) {
+	if t == nil {
+		return nil, nil
+	}
+	r, err := fn(ptr, parent, t)
+	if err == SkipSubschemas {
+		return r, nil
+	} else if err != nil || r == nil {
+		return nil, err
+	}
+	r = r.mapSubschemas(func(sub string, c *Type) *Type {
+		if err != nil {
+			return c
+		}
+		var tc *Type
+		tc, err = transform(ptr+sub, r, c, fn)
+		return tc
+	})
+	return r, err
+}
+
+// eachSubschema calls fn with each direct subschema of t and the JSON Pointer
+// to it relative to t, in keyword order. Changes fn makes to the subschema of
+// additionalProperties are written back to t.
+func (t *Type) eachSubschema(fn func(ptr string, c *Type)) {
+	c := t.mapSubschemas(func(ptr string, c *Type) *Type {
+		fn(ptr, c)
+		return c
+	})
+	if !bytes.Equal(c.AdditionalProperties, t.AdditionalProperties) {
+		t.AdditionalProperties = c.AdditionalProperties
+	}
+}
+
+// mapSubschemas returns a shallow copy of t in which each direct subschema
+// has been replaced by the result of calling fn with it and the JSON Pointer
+// to it relative to t. Subschemas of map and slice keywords are removed if
+// fn returns nil. Subschemas are visited in keyword order, and map keys in
+// sorted order, apart from properties which keep their own order.
+//
+// Every keyword of Type holding subschemas must be handled here, so that
+// functions built on it cover the whole schema.
+func (t *Type) mapSubschemas(fn func(ptr string, c *Type) *Type) *Type {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	c.AdditionalItems = mapSubschema(t.AdditionalItems, "/additionalItems", fn)
+	c.Items = mapSubschema(t.Items, "/items", fn)
+	if t.Properties != nil {
+		c.Properties = orderedmap.New()
+		for _, k := range t.Properties.Keys() {
+			v, _ := t.Properties.Get(k)
+			if p, ok := v.(*Type); ok {
+				if p = fn("/properties/"+escapePointer(k), p); p == nil {
+					continue
+				}
+				v = p
+			}
+			c.Properties.Set(k, v)
+		}
+	}
+	c.PatternProperties = mapSubschemaMap(t.PatternProperties, "/patternProperties/", fn)
+	c.AdditionalProperties = mapRawSubschema(t.AdditionalProperties, "/additionalProperties", fn)
+	c.Dependencies = mapSubschemaMap(t.Dependencies, "/dependencies/", fn)
+	c.AllOf = mapSubschemaSlice(t.AllOf, "/allOf/", fn)
+	c.AnyOf = mapSubschemaSlice(t.AnyOf, "/anyOf/", fn)
+	c.OneOf = mapSubschemaSlice(t.OneOf, "/oneOf/", fn)
+	c.Not = mapSubschema(t.Not, "/not", fn)
+	if t.Definitions != nil {
+		c.Definitions = mapSubschemaMap(t.Definitions, "/definitions/", fn)
+	}
+	c.Media = mapSubschema(t.Media, "/media", fn)
+	return &c
+}
+
+func mapSubschema(t *Type, ptr string, fn func(string, *Type) *Type) *Type {
+	if t == nil {
+		return nil
+	}
+	return fn(ptr, t)
+}
+
+func mapSubschemaSlice(ts []*Type, ptr string, fn func(string, *Type) *Type) []*Type {
+	if ts == nil {
+		return nil
+	}
+	c := make([]*Type, 0, len(ts))
+	for i, t := range ts {
+		if t = mapSubschema(t, ptr+strconv.Itoa(i), fn); t != nil {
+			c = append(c, t)
+		}
+	}
+	return c
+}
+
+func mapSubschemaMap(ts map[string]*Type, ptr string, fn func(string, *Type) *Type) map[string]*Type {
+	if ts == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(ts))
+	for k := range ts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	c := make(map[string]*Type, len(ts))
+	for _, k := range keys {
+		if t := mapSubschema(ts[k], ptr+escapePointer(k), fn); t != nil {
+			c[k] = t
+		}
+	}
+	return c
+}
+
+// mapRawSubschema maps additionalProperties, which holds a subschema when it
+// isn't a boolean.
+func mapRawSubschema(raw json.RawMessage, ptr string, fn func(string, *Type) *Type) json.RawMessage {
+	if !bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		return raw
+	}
+	t := &Type{}
+	if err := json.Unmarshal(raw, t); err != nil {
+		return raw
+	}
+	// raw is kept as it is unless fn changed the schema, which it may do in
+	// place.
+	before, err := json.Marshal(t)
+	if err != nil {
+		return raw
+	}
+	if t = fn(ptr, t); t == nil {
+		return nil
+	}
+	b, err := json.Marshal(t)
+	if err != nil || bytes.Equal(b, before) {
+		return raw
+	}
+	return b
+}
+
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// escapePointer escapes a JSON Pointer reference token, RFC 6901 section 3.
+func escapePointer(token string) string {
+	return pointerEscaper.Replace(token)
+}
+
+// unescapePointer reverses escapePointer.
+func unescapePointer(token string) string {
+	return pointerUnescaper.Replace(token)
+}
@@ -0,0 +1,117 @@
+package jsonschema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalk(t *testing.T) {
+	s := Reflect(&Segment{})
+	var ptrs []string
+	parents := map[string]*Type{}
+	err := WalkSchema(s, func(ptr string, parent, t *Type) error {
+		ptrs = append(ptrs, ptr)
+		parents[ptr] = parent
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"",
+		"/definitions/Point",
+		"/definitions/Point/properties/x",
+		"/definitions/Point/properties/y",
+		"/definitions/Segment",
+		"/definitions/Segment/properties/from",
+		"/definitions/Segment/properties/to",
+	}, ptrs)
+	require.Nil(t, parents["/definitions/Point"])
+	require.Equal(t, s.Definitions["Point"], parents["/definitions/Point/properties/x"])
+}
+
+func TestWalkSkipAndStop(t *testing.T) {
+	root := &Type{
+		Items:                &Type{Not: &Type{Type: "string"}},
+		AllOf:                []*Type{{Type: "a/b"}},
+		AdditionalProperties: []byte(`{"type":"integer"}`),
+	}
+
+	var ptrs []string
+	err := Walk(root, func(ptr string, _, t *Type) error {
+		ptrs = append(ptrs, ptr)
+		if ptr == "/items" {
+			return SkipSubschemas
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"", "/items", "/additionalProperties", "/allOf/0"}, ptrs)
+
+	stop := errors.New("stop")
+	ptrs = nil
+	err = Walk(root, func(ptr string, _, t *Type) error {
+		ptrs = append(ptrs, ptr)
+		if ptr == "/items" {
+			return stop
+		}
+		return nil
+	})
+	require.Equal(t, stop, err)
+	require.Equal(t, []string{"", "/items"}, ptrs)
+}
+
+func TestWalkAdditionalProperties(t *testing.T) {
+	raw := []byte(`{"type": "object", "properties": {"n": {"type": "integer"}}}`)
+	root := &Type{AdditionalProperties: raw}
+	err := Walk(root, func(ptr string, _, t *Type) error {
+		if ptr == "/additionalProperties/properties/n" {
+			t.Description = "A number."
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"object","properties":{"n":{"type":"integer","description":"A number."}}}`, string(root.AdditionalProperties))
+
+	// Schemas that aren't changed are kept as they are.
+	root = &Type{AdditionalProperties: raw}
+	require.NoError(t, Walk(root, func(string, *Type, *Type) error { return nil }))
+	require.Equal(t, raw, []byte(root.AdditionalProperties))
+}
+
+func TestTransformAdditionalProperties(t *testing.T) {
+	root := &Type{AdditionalProperties: []byte(`{"type":"integer"}`)}
+	transformed, err := Transform(root, func(ptr string, _, t *Type) (*Type, error) {
+		if t.Type == "integer" {
+			return &Type{Type: "number"}, nil
+		}
+		return t, nil
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"number"}`, string(transformed.AdditionalProperties))
+	require.JSONEq(t, `{"type":"integer"}`, string(root.AdditionalProperties))
+}
+
+func TestTransform(t *testing.T) {
+	s := Reflect(&Segment{})
+	transformed, err := TransformSchema(s, func(ptr string, _, t *Type) (*Type, error) {
+		switch {
+		case ptr == "/definitions/Point/properties/y":
+			return nil, nil
+		case t.Type == "integer":
+			return &Type{Type: "number"}, nil
+		}
+		return t, nil
+	})
+	require.NoError(t, err)
+
+	point := transformed.Definitions["Point"]
+	require.Equal(t, []string{"x"}, point.Properties.Keys())
+	x, _ := point.Properties.Get("x")
+	require.Equal(t, "number", x.(*Type).Type)
+
+	// The original schema is untouched.
+	require.Equal(t, []string{"x", "y"}, s.Definitions["Point"].Properties.Keys())
+	x, _ = s.Definitions["Point"].Properties.Get("x")
+	require.Equal(t, "integer", x.(*Type).Type)
+}
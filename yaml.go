@@ -0,0 +1,198 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalYAML produces the same keywords as MarshalJSON, in the same order,
+// as a YAML document.
+func (s *Schema) MarshalYAML() (interface{}, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return jsonToYAML(b)
+}
+
+// MarshalYAML produces the same keywords as MarshalJSON, in the same order,
+// as a YAML document.
+func (t *Type) MarshalYAML() (interface{}, error) {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return jsonToYAML(b)
+}
+
+// WriteYAML writes s to w as a YAML document, indented by two spaces.
+func (s *Schema) WriteYAML(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// ReadYAML loads a schema from the YAML document in r, as UnmarshalYAML
+// does.
+func ReadYAML(r io.Reader) (*Schema, error) {
+	s := &Schema{}
+	if err := yaml.NewDecoder(r).Decode(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// UnmarshalYAML loads a schema from YAML as UnmarshalJSON loads it from the
+// equivalent JSON.
+func (s *Schema) UnmarshalYAML(node *yaml.Node) error {
+	b, err := yamlToJSON(node)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, s)
+}
+
+// UnmarshalYAML loads a schema from YAML as UnmarshalJSON loads it from the
+// equivalent JSON.
+func (t *Type) UnmarshalYAML(node *yaml.Node) error {
+	b, err := yamlToJSON(node)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, t)
+}
+
+// jsonToYAML converts a JSON document to a YAML node, keeping the order of
+// the keys of its objects.
+func jsonToYAML(data []byte) (*yaml.Node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return jsonTokenToYAML(dec)
+}
+
+func jsonTokenToYAML(dec *json.Decoder) (*yaml.Node, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if token == '{' {
+			node.Kind, node.Tag = yaml.MappingNode, "!!map"
+		}
+		for dec.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+			value, err := jsonTokenToYAML(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		// Consume the closing delimiter.
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: token}, nil
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(token.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: token.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(token)}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
+
+// yamlToJSON converts a YAML node to a JSON document, keeping the order of
+// the keys of its mappings.
+func yamlToJSON(node *yaml.Node) ([]byte, error) {
+	b := &bytes.Buffer{}
+	if err := writeYAMLAsJSON(b, node); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func writeYAMLAsJSON(b *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			b.WriteString("null")
+			return nil
+		}
+		return writeYAMLAsJSON(b, node.Content[0])
+
+	case yaml.AliasNode:
+		return writeYAMLAsJSON(b, node.Alias)
+
+	case yaml.MappingNode:
+		b.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind != yaml.ScalarNode {
+				return fmt.Errorf("jsonschema: line %d: mapping keys must be scalars", key.Line)
+			}
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			k, _ := json.Marshal(key.Value)
+			b.Write(k)
+			b.WriteByte(':')
+			if err := writeYAMLAsJSON(b, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+
+	case yaml.SequenceNode:
+		b.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeYAMLAsJSON(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+
+	case yaml.ScalarNode:
+		var v interface{}
+		switch node.ShortTag() {
+		case "!!int", "!!float", "!!bool", "!!null":
+			if err := node.Decode(&v); err != nil {
+				return err
+			}
+		default:
+			// Strings, and scalars like timestamps that have no JSON
+			// equivalent, are kept as written.
+			v = node.Value
+		}
+		s, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("jsonschema: line %d: %w", node.Line, err)
+		}
+		b.Write(s)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteYAML(t *testing.T) {
+	s := &Schema{
+		Type: &Type{
+			Ref: "#/definitions/Item",
+		},
+		Definitions: Definitions{
+			"Item": {
+				Type: "object",
+				Properties: props(
+					"name", &Type{Type: "string", Enum: []interface{}{"true", "1.5", "plain"}},
+					"count", &Type{Type: "integer", Minimum: 1, Default: 3},
+					"ratio", &Type{Type: "number", Extras: map[string]interface{}{"x-unit": "percent"}},
+				),
+				Required:             []string{"name"},
+				AdditionalProperties: []byte("false"),
+			},
+		},
+	}
+	b := &bytes.Buffer{}
+	require.NoError(t, s.WriteYAML(b))
+	require.Equal(t, `$ref: '#/definitions/Item'
+definitions:
+  Item:
+    required:
+      - name
+    properties:
+      name:
+        enum:
+          - "true"
+          - "1.5"
+          - plain
+        type: string
+      count:
+        minimum: 1
+        type: integer
+        default: 3
+      ratio:
+        type: number
+        x-unit: percent
+    additionalProperties: false
+    type: object
+`, b.String())
+
+	loaded, err := ReadYAML(b)
+	require.NoError(t, err)
+	expected, err := json.Marshal(s)
+	require.NoError(t, err)
+	actual, err := json.Marshal(loaded)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(actual))
+}
+
+func TestYAMLRoundTripFixtures(t *testing.T) {
+	paths, err := filepath.Glob("fixtures/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			s := loadSchema(t, path)
+			expected, err := json.MarshalIndent(s, "", "  ")
+			require.NoError(t, err)
+
+			b := &bytes.Buffer{}
+			require.NoError(t, s.WriteYAML(b))
+			loaded, err := ReadYAML(b)
+			require.NoError(t, err)
+			actual, err := json.MarshalIndent(loaded, "", "  ")
+			require.NoError(t, err)
+			require.Equal(t, string(expected), string(actual))
+		})
+	}
+}
+
+func TestTypeYAML(t *testing.T) {
+	b, err := yaml.Marshal(Reflect(&TestUser{}).Definitions["TestUser"])
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(b), "required:\n"), string(b))
+
+	var typ Type
+	require.NoError(t, yaml.Unmarshal(b, &typ))
+	expected, err := json.Marshal(Reflect(&TestUser{}).Definitions["TestUser"])
+	require.NoError(t, err)
+	actual, err := json.Marshal(&typ)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(actual))
+}
+
+func TestReadYAML(t *testing.T) {
+	s, err := ReadYAML(strings.NewReader(`
+type: object
+properties:
+  b: &string
+    type: string
+    format: date
+    default: 2020-01-02
+  a: *string
+  c:
+    type: integer
+    maximum: 0x10
+additionalProperties:
+  type: boolean
+x-note: kept
+`))
+	require.NoError(t, err)
+	b, err := json.Marshal(s)
+	require.NoError(t, err)
+	require.Equal(t, `{"properties":{"b":{"type":"string","default":"2020-01-02","format":"date"},`+
+		`"a":{"type":"string","default":"2020-01-02","format":"date"},"c":{"maximum":16,"type":"integer"}},`+
+		`"additionalProperties":{"type":"boolean"},"type":"object","x-note":"kept"}`, string(b))
+
+	_, err = ReadYAML(strings.NewReader("type: [object"))
+	require.Error(t, err)
+}